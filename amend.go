@@ -0,0 +1,236 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/qmuntal/stateless"
+)
+
+var (
+	ErrUnmappedState        = errors.New("current state does not map onto the amended contract")
+	ErrUnmappedVariable     = errors.New("variable does not map onto the amended contract")
+	ErrAmendmentNotApproved = errors.New("amendment is not approved")
+)
+
+// AmendmentMapping maps the States and Variables of a Contract onto an amended Contract, by name. States and
+// Variables that are not mapped map onto those of the same name.
+type AmendmentMapping struct {
+	// States maps the name of a State to the name of a State of the amended Contract. E.g., {"Review": "Legal Review"}
+	States map[string]string `json:"states,omitempty" yaml:"states,omitempty" toml:"states,omitempty"`
+	// Variables maps the name of a Variable to the name of a Variable of the amended Contract.
+	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty" toml:"variables,omitempty"`
+}
+
+func (m AmendmentMapping) state(name string) string {
+	if to, ok := m.States[name]; ok {
+		return to
+	}
+	return name
+}
+
+func (m AmendmentMapping) variable(name string) string {
+	if to, ok := m.Variables[name]; ok {
+		return to
+	}
+	return name
+}
+
+// Amend migrates the Status of a running Contract onto an amended Contract. The current States and Variables
+// must map onto the amended Contract; Regions added by the amendment start in their Initial State. The amendment
+// must be approved: the amended Contract must be signed by every Party that signed the Contract, or by at least
+// one of its Parties if it is unsigned. Approvals are verified against the PublicKeys pinned by the Parties of the
+// Contract, so that an amendment cannot approve itself by pinning new keys. The Status of the amended Contract
+// is replaced with the migrated Status, and an AuditEntry recording the amendment is appended to its Audit
+// trail and returned. The digests of the AuditEntry cover the same DigestOptions.
+func Amend(old, amended *Contract, mapping AmendmentMapping, opts ...DigestOption) (*AuditEntry, error) {
+	if err := amended.Validate(); err != nil {
+		return nil, err
+	}
+	approvers, err := approvers(old, amended, opts...)
+	if err != nil {
+		return nil, err
+	}
+	current, details, err := migrateStates(old, amended, mapping)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := migrateVariables(old, amended, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := newAuditEntry(AuditAmendment)
+	entry.Parties = approvers
+	if entry.From, err = old.Digest(opts...); err != nil {
+		return nil, err
+	}
+	if entry.To, err = amended.Digest(opts...); err != nil {
+		return nil, err
+	}
+	entry.Details = append(Diff(old, amended).Summary(), details...)
+
+	status := old.Status
+	status.CurrentState = current
+	status.Variables = variables
+	status.Audit = append(slices.Clone(old.Status.Audit), entry)
+	amended.Status = status
+	return &entry, nil
+}
+
+// approvers verifies the approval of an amendment and returns the Parties that approved it.
+func approvers(old, amended *Contract, opts ...DigestOption) ([]string, error) {
+	if err := amended.verifySignatures(old, opts...); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrAmendmentNotApproved, err)
+	}
+	signed := make(map[string]bool)
+	for _, sig := range amended.Signatures {
+		signed[sig.Party] = true
+	}
+	for _, sig := range old.Signatures {
+		if !signed[sig.Party] {
+			return nil, fmt.Errorf("%w: party %s has not signed the amendment", ErrAmendmentNotApproved, sig.Party)
+		}
+	}
+	return slices.Sorted(maps.Keys(signed)), nil
+}
+
+// migrateStates maps the current States of a Contract onto the amended Contract, returning the current States
+// of the amended Contract in Status order and a description of each State mapped onto another.
+func migrateStates(old, amended *Contract, mapping AmendmentMapping) ([]string, []string, error) {
+	current := old.Status.CurrentState
+	if len(current) == 0 {
+		current = []string{old.State.Initial}
+	}
+
+	var details []string
+	byRegion := make(map[string]string)
+	for _, name := range current {
+		to := mapping.state(name)
+		if _, err := amended.GetState(to); err != nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrUnmappedState, name)
+		}
+		region := amended.RegionOf(to)
+		if _, ok := byRegion[region]; ok {
+			return nil, nil, fmt.Errorf("%w: %s and %s map onto the same region", ErrUnmappedState, byRegion[region], to)
+		}
+		byRegion[region] = to
+		if to != name {
+			details = append(details, fmt.Sprintf("state %s mapped to %s", name, to))
+		}
+	}
+
+	root, ok := byRegion[""]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: no current state maps onto the state configuration", ErrUnmappedState)
+	}
+	states := []string{root}
+	for _, region := range amended.State.Regions {
+		state, ok := byRegion[region.Name]
+		if !ok {
+			state = region.Initial
+		}
+		states = append(states, state)
+	}
+	return states, details, nil
+}
+
+// migrateVariables maps the current values of the Variables of a Contract onto the amended Contract.
+func migrateVariables(old, amended *Contract, mapping AmendmentMapping) (map[string]string, error) {
+	declared := make(map[string]bool)
+	for _, s := range amended.States() {
+		for _, v := range s.Variables {
+			declared[v.Name] = true
+		}
+	}
+	var variables map[string]string
+	for name, value := range old.Status.Variables {
+		to := mapping.variable(name)
+		if !declared[to] {
+			return nil, fmt.Errorf("%w: %s", ErrUnmappedVariable, name)
+		}
+		if variables == nil {
+			variables = make(map[string]string)
+		}
+		variables[to] = value
+	}
+	return variables, nil
+}
+
+// Amend migrates the running SLC onto an amended Contract. See Amend. The DigestOptions must include the
+// policy and text content the amendment was signed with. The FSMs of the SLC are rebuilt with the FSMOptions
+// in the migrated States, and the timed Transitions of the active States are rescheduled; Timers of timed
+// Transitions that remain active, including in a State mapped onto another, keep their due time. No Entry or
+// Exit actions are reconciled. Events and Timers are not consumed while the SLC is amended.
+func (r *Reconciler) Amend(ctx context.Context, amended *Contract, mapping AmendmentMapping, digest []DigestOption, opts ...FSMOption) (*AuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updateStatus(ctx)
+	entry, err := Amend(r.Contract, amended, mapping, digest...)
+	if err != nil {
+		return nil, err
+	}
+
+	fsm, err := NewStateMachine(ctx, amended.Status.CurrentState[0], amended, opts...)
+	if err != nil {
+		return nil, err
+	}
+	regions := make(map[string]*stateless.StateMachine)
+	for i, region := range amended.State.Regions {
+		if regions[region.Name], err = NewRegionStateMachine(ctx, region.Name, amended.Status.CurrentState[i+1], amended, opts...); err != nil {
+			return nil, err
+		}
+	}
+	r.Contract, r.FSM, r.Regions = amended, fsm, regions
+	r.FSM.OnTransitioning(r.transitioning(ctx))
+	for _, sm := range r.Regions {
+		sm.OnTransitioning(r.transitioning(ctx))
+	}
+
+	var active []State
+	for _, region := range r.regions() {
+		states, err := r.activeStates(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		active = append(active, states...)
+	}
+	timers, err := r.Scheduler.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range timers {
+		state := mapping.state(t.State)
+		remains := slices.ContainsFunc(active, func(s State) bool {
+			return s.Name == state && slices.ContainsFunc(s.Transitions, func(tr Transition) bool {
+				return tr.Name == t.Transition && tr.On == t.Event && tr.IsTimed()
+			})
+		})
+		if remains && state == t.State {
+			continue
+		}
+		if err = r.Scheduler.store.Delete(ctx, t.ID); err != nil {
+			return nil, err
+		}
+		if !remains {
+			continue
+		}
+		// The Timer of a State mapped onto another is migrated with its due time.
+		t.ID, t.State = timerID(state, t.Transition), state
+		if err = r.Scheduler.store.Put(ctx, t); err != nil {
+			return nil, err
+		}
+	}
+	for _, s := range active {
+		if err = r.schedule(ctx, s); err != nil {
+			return nil, fmt.Errorf("failed to schedule timed transitions: %w", err)
+		}
+	}
+
+	r.Logger.Info("Smart Legal Contract amended", "Contract", r.Contract.Name, "From", entry.From, "To", entry.To)
+	return entry, nil
+}
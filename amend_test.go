@@ -0,0 +1,161 @@
+package slc
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// amendmentContracts returns a running Contract signed by the buyer, and an amendment renaming its
+// "In Process" State to "Active", with the signing keys of the Parties.
+func amendmentContracts(t *testing.T) (*Contract, *Contract, map[string]ed25519.PrivateKey) {
+	t.Helper()
+	keys := make(map[string]ed25519.PrivateKey)
+	for _, party := range []string{"buyer", "seller"} {
+		_, keys[party], _ = ed25519.GenerateKey(rand.Reader)
+	}
+	parties := []Party{pinnedParty(t, "buyer", keys["buyer"]), pinnedParty(t, "seller", keys["seller"])}
+	old, err := GetFSContract("./tests/timers_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old.Parties = parties
+	if _, err = old.Sign("buyer", keys["buyer"]); err != nil {
+		t.Fatal(err)
+	}
+	old.Status.CurrentState = []string{"In Process"}
+	old.Status.Variables = map[string]string{"signingDeadline": "2025-02-28T00:00:00Z"}
+
+	amended, err := GetFSContract("./tests/timers_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	amended.Parties = parties
+	amended.State.States[0].Transitions[0].To = "Active"
+	amended.State.States[1].Name = "Active"
+	return old, amended, keys
+}
+
+func TestAmend(t *testing.T) {
+	old, amended, keys := amendmentContracts(t)
+	mapping := AmendmentMapping{States: map[string]string{"In Process": "Active"}}
+
+	if _, err := Amend(old, amended, mapping); !errors.Is(err, ErrAmendmentNotApproved) {
+		t.Fatalf("expected %v, got %v", ErrAmendmentNotApproved, err)
+	}
+	if _, err := amended.Sign("seller", keys["seller"]); err != nil {
+		t.Fatal(err)
+	}
+	// The buyer signed the Contract, and must approve the amendment.
+	if _, err := Amend(old, amended, mapping); !errors.Is(err, ErrAmendmentNotApproved) {
+		t.Fatalf("expected %v, got %v", ErrAmendmentNotApproved, err)
+	}
+	if _, err := amended.Sign("buyer", keys["buyer"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Amend(old, amended, AmendmentMapping{}); !errors.Is(err, ErrUnmappedState) {
+		t.Fatalf("expected %v, got %v", ErrUnmappedState, err)
+	}
+	old.Status.Variables["amount"] = "5000"
+	if _, err := Amend(old, amended, mapping); !errors.Is(err, ErrUnmappedVariable) {
+		t.Fatalf("expected %v, got %v", ErrUnmappedVariable, err)
+	}
+	delete(old.Status.Variables, "amount")
+
+	entry, err := Amend(old, amended, mapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Type != AuditAmendment || !equalSlices(entry.Parties, []string{"buyer", "seller"}) {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+	expected := []string{"state In Process renamed to Active", "state In Process mapped to Active"}
+	if !equalSlices(entry.Details, expected) {
+		t.Fatalf("expected %v, got %v", expected, entry.Details)
+	}
+	if digest, _ := amended.Digest(); entry.To != digest {
+		t.Fatalf("expected digest %s, got %s", digest, entry.To)
+	}
+	if !equalSlices(amended.Status.CurrentState, []string{"Active"}) || amended.Status.Variables["signingDeadline"] != "2025-02-28T00:00:00Z" {
+		t.Fatalf("unexpected status %+v", amended.Status)
+	}
+	if len(amended.Status.Audit) != 1 || len(old.Status.Audit) != 0 {
+		t.Fatalf("unexpected audit trails %v, %v", amended.Status.Audit, old.Status.Audit)
+	}
+}
+
+func TestAmendPinnedKeys(t *testing.T) {
+	old, amended, _ := amendmentContracts(t)
+	mapping := AmendmentMapping{States: map[string]string{"In Process": "Active"}}
+
+	// The amendment pins a new key for the buyer, which cannot approve it.
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	amended.Parties = []Party{pinnedParty(t, "buyer", key), amended.Parties[1]}
+	if _, err := amended.Sign("buyer", key); err != nil {
+		t.Fatal(err)
+	}
+	if err := amended.Verify(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_, err := Amend(old, amended, mapping)
+	if !errors.Is(err, ErrAmendmentNotApproved) || !errors.Is(err, ErrPartyKeyMismatch) {
+		t.Fatalf("expected %v, got %v", ErrPartyKeyMismatch, err)
+	}
+}
+
+func TestReconcilerAmend(t *testing.T) {
+	ctx := context.Background()
+	old, amended, keys := amendmentContracts(t)
+	text := WithTextContent([]byte("amended agreement"))
+	if _, err := amended.Sign("buyer", keys["buyer"], text); err != nil {
+		t.Fatal(err)
+	}
+
+	sm, err := NewStateMachine(ctx, "In Process", old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(old, sm, nil, nil, ReconcilerConfig{}, WithClock(clock), WithReconcilerLogger(logger))
+	inProcess, _ := old.GetState("In Process")
+	if err = r.Scheduler.Schedule(ctx, inProcess); err != nil {
+		t.Fatal(err)
+	}
+
+	scheduled := clock.Now().Add(72 * time.Hour)
+
+	clock.Advance(time.Hour)
+	mapping := AmendmentMapping{States: map[string]string{"In Process": "Active"}}
+	// The amendment is approved over the content it was signed with.
+	if _, err = r.Amend(ctx, amended, mapping, nil); !errors.Is(err, ErrContractTampered) {
+		t.Fatalf("expected %v, got %v", ErrContractTampered, err)
+	}
+	if _, err = r.Amend(ctx, amended, mapping, []DigestOption{text}); err != nil {
+		t.Fatal(err)
+	}
+	if r.Contract != amended {
+		t.Fatal("expected the reconciler to run the amended contract")
+	}
+	if state, _ := r.FSM.State(ctx); state != "Active" {
+		t.Fatalf("expected state Active, got %v", state)
+	}
+
+	// The timer of the renamed State is migrated with its due time.
+	if _, err = r.Scheduler.store.Get(ctx, timerID("In Process", "Payment Overdue")); !errors.Is(err, ErrTimerNotFound) {
+		t.Fatalf("expected %v, got %v", ErrTimerNotFound, err)
+	}
+	tm, err := r.Scheduler.store.Get(ctx, timerID("Active", "Payment Overdue"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.FireAt.Equal(scheduled) {
+		t.Fatalf("expected timer at %v, got %v", scheduled, tm.FireAt)
+	}
+}
@@ -0,0 +1,59 @@
+package slc
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// AuditAmendment is the Type of AuditEntries recording the amendment of the SLC definition.
+	AuditAmendment = "amendment"
+	// AuditTransition is the Type of AuditEntries recording a Transition of the running SLC.
+	AuditTransition = "transition"
+)
+
+// An AuditEntry records a change to a running SLC in its Status.
+type AuditEntry struct {
+	// The unique identifier (UUID) of the AuditEntry
+	ID string `json:"id" yaml:"id" toml:"id"`
+	// The Type of change. E.g., "amendment"
+	Type string `json:"type" yaml:"type" toml:"type"`
+	// Time is the RFC3339 timestamp of the change.
+	Time string `json:"time" yaml:"time" toml:"time"`
+	// Parties are the identifiers of the Parties that performed or approved the change.
+	Parties []string `json:"parties,omitempty" yaml:"parties,omitempty" toml:"parties,omitempty"`
+	// From is the state before the change. For amendments, the content digest of the previous definition.
+	From string `json:"from,omitempty" yaml:"from,omitempty" toml:"from,omitempty"`
+	// To is the state after the change. For amendments, the content digest of the amended definition.
+	To string `json:"to,omitempty" yaml:"to,omitempty" toml:"to,omitempty"`
+	// Details of the change. E.g., "state Review added"
+	Details []string `json:"details,omitempty" yaml:"details,omitempty" toml:"details,omitempty"`
+	// Decisions are the IDs of the PolicyDecisions of the guard Conditions of a Transition.
+	Decisions []string `json:"decisions,omitempty" yaml:"decisions,omitempty" toml:"decisions,omitempty"`
+}
+
+// newAuditEntry creates an AuditEntry of the given Type at the current time.
+func newAuditEntry(auditType string) AuditEntry {
+	return AuditEntry{
+		ID:   uuid.New().String(),
+		Type: auditType,
+		Time: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// newTransitionAuditEntry creates the AuditEntry of a Transition fired in a Region, or in the StateConfiguration
+// for "", from one State to another.
+func newTransitionAuditEntry(region string, t Transition, from, to string, input *TransitionCtx) AuditEntry {
+	entry := newAuditEntry(AuditTransition)
+	entry.From, entry.To = from, to
+	entry.Details = []string{"transition " + t.Name}
+	if region != "" {
+		entry.Details = append(entry.Details, "region "+region)
+	}
+	if input.Party != nil {
+		entry.Parties = []string{input.Party.ID}
+	}
+	entry.Decisions = input.Decisions
+	return entry
+}
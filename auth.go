@@ -0,0 +1,291 @@
+package slc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/zitadel/oidc/v3/pkg/client"
+	"github.com/zitadel/oidc/v3/pkg/client/rp"
+	"github.com/zitadel/oidc/v3/pkg/oidc"
+)
+
+const (
+	// AuthTokenExtension is the CloudEvent extension attribute carrying a JWT issued to the event producer.
+	AuthTokenExtension = "authtoken"
+	// AuthSignatureExtension is the CloudEvent extension attribute carrying a detached JWS made by
+	// the Party named in the PartyExtension attribute.
+	AuthSignatureExtension = "authsignature"
+
+	// DefaultSignedEventMaxAge is how long after its time a signed event is accepted by default.
+	DefaultSignedEventMaxAge = 5 * time.Minute
+)
+
+var (
+	// ErrNoCredentials is returned by an EventAuthenticator when the event does not carry the
+	// credentials it verifies.
+	ErrNoCredentials        = errors.New("event carries no credentials")
+	ErrEventUnauthenticated = errors.New("event producer could not be authenticated")
+	ErrPartyMismatch        = errors.New("authenticated party does not match the event party")
+	ErrEventExpired         = errors.New("signed event is outside the accepted time window")
+	ErrEventReplayed        = errors.New("signed event has already been authenticated")
+)
+
+// EventClaims are the verified claims of an authenticated event producer.
+type EventClaims struct {
+	// Issuer of the credentials. Empty for Party signatures.
+	Issuer string `json:"issuer,omitempty"`
+	// Subject of the credentials. The Party ID for Party signatures.
+	Subject string `json:"subject"`
+	// Party is the Contract Party matching the credentials, if any.
+	Party *Party `json:"-"`
+	// Claims are all verified token claims.
+	Claims map[string]interface{} `json:"claims,omitempty"`
+}
+
+// An EventAuthenticator verifies the authenticity of a CloudEvent before it can trigger a Transition.
+// Authenticate returns ErrNoCredentials if the event does not carry credentials it can verify.
+type EventAuthenticator interface {
+	Authenticate(ctx context.Context, event cloudevents.Event) (*EventClaims, error)
+}
+
+// OIDCAuthenticator verifies a JWT carried in the AuthTokenExtension attribute against the
+// Network OIDC Issuer.
+type OIDCAuthenticator struct {
+	contract *Contract
+	issuer   string
+	audience string
+	keySet   oidc.KeySet
+}
+
+// NewOIDCAuthenticator creates an OIDCAuthenticator for the Contract Network. The signing keys of the
+// Issuer are located using the Network DiscoveryEndpoint, or the Issuer well-known configuration if the
+// DiscoveryEndpoint is empty. Tokens must include the Network ClientID as an audience when it is set.
+func NewOIDCAuthenticator(ctx context.Context, c *Contract, httpClient *http.Client) (*OIDCAuthenticator, error) {
+	if c.Network.Issuer == "" {
+		return nil, errors.New("network issuer is required for OIDC authentication")
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	discovery, err := client.Discover(ctx, c.Network.Issuer, httpClient, c.Network.DiscoveryEndpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC configuration: %w", err)
+	}
+	return &OIDCAuthenticator{
+		contract: c,
+		issuer:   c.Network.Issuer,
+		audience: c.Network.ClientID,
+		keySet:   rp.NewRemoteKeySet(httpClient, discovery.JwksURI),
+	}, nil
+}
+
+// Authenticate verifies the signature, issuer, audience and expiration of the event token.
+func (a *OIDCAuthenticator) Authenticate(ctx context.Context, event cloudevents.Event) (*EventClaims, error) {
+	token, ok := event.Extensions()[AuthTokenExtension].(string)
+	if !ok || token == "" {
+		return nil, ErrNoCredentials
+	}
+	token = strings.TrimPrefix(token, "Bearer ")
+
+	claims := new(oidc.AccessTokenClaims)
+	payload, err := oidc.ParseToken(token, claims)
+	if err != nil {
+		return nil, err
+	}
+	if err = oidc.CheckSubject(claims); err != nil {
+		return nil, err
+	}
+	if err = oidc.CheckIssuer(claims, a.issuer); err != nil {
+		return nil, err
+	}
+	if a.audience != "" {
+		if err = oidc.CheckAudience(claims, a.audience); err != nil {
+			return nil, err
+		}
+	}
+	if err = oidc.CheckSignature(ctx, token, payload, claims, nil, a.keySet); err != nil {
+		return nil, err
+	}
+	if err = oidc.CheckExpiration(claims, 0); err != nil {
+		return nil, err
+	}
+
+	ec := &EventClaims{
+		Issuer:  claims.Issuer,
+		Subject: claims.Subject,
+		Claims:  claims.Claims,
+	}
+	if p, err := a.contract.GetPartyBySubject(claims.Issuer, claims.Subject); err == nil {
+		ec.Party = &p
+	}
+	return ec, nil
+}
+
+// PartySignatureAuthenticator verifies a detached JWS carried in the AuthSignatureExtension attribute
+// against the PublicKey of the Party named in the PartyExtension attribute. The signature covers the event
+// time: events are only accepted within MaxAge of their time, and only once.
+type PartySignatureAuthenticator struct {
+	// MaxAge is how long after its time, or before it, a signed event is accepted. Defaults to
+	// DefaultSignedEventMaxAge.
+	MaxAge time.Duration
+
+	contract *Contract
+	mu       sync.Mutex
+	// seen holds the time of the events authenticated within MaxAge, by event source and ID.
+	seen map[string]time.Time
+}
+
+// NewPartySignatureAuthenticator creates a PartySignatureAuthenticator for the Contract Parties.
+func NewPartySignatureAuthenticator(c *Contract) *PartySignatureAuthenticator {
+	return &PartySignatureAuthenticator{MaxAge: DefaultSignedEventMaxAge, contract: c, seen: make(map[string]time.Time)}
+}
+
+// Authenticate verifies the event signature using the public key declared by the Party, and that the
+// event was neither signed outside of MaxAge nor authenticated before.
+func (a *PartySignatureAuthenticator) Authenticate(_ context.Context, event cloudevents.Event) (*EventClaims, error) {
+	value, ok := event.Extensions()[AuthSignatureExtension].(string)
+	if !ok || value == "" {
+		return nil, ErrNoCredentials
+	}
+	id, ok := event.Extensions()[PartyExtension].(string)
+	if !ok || id == "" {
+		return nil, fmt.Errorf("%s extension is required with %s", PartyExtension, AuthSignatureExtension)
+	}
+	party, err := a.contract.GetParty(id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, id)
+	}
+	if party.PublicKey == "" {
+		return nil, fmt.Errorf("party %s has no public key", id)
+	}
+	key, err := parsePublicKey(party.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := eventSigningPayload(event)
+	jws, err := jose.ParseDetached(value, payload, supportedSignatureAlgorithms)
+	if err != nil {
+		return nil, err
+	}
+	if err = jws.DetachedVerify(payload, key); err != nil {
+		return nil, err
+	}
+	if err = a.consume(event); err != nil {
+		return nil, err
+	}
+	return &EventClaims{Subject: party.ID, Party: &party}, nil
+}
+
+// consume records a signed event as authenticated. Events outside of MaxAge are rejected, so that only the
+// events within MaxAge need to be remembered to reject replays.
+func (a *PartySignatureAuthenticator) consume(event cloudevents.Event) error {
+	maxAge := a.MaxAge
+	if maxAge <= 0 {
+		maxAge = DefaultSignedEventMaxAge
+	}
+	now := time.Now()
+	if event.Time().IsZero() || now.Sub(event.Time()).Abs() > maxAge {
+		return fmt.Errorf("%w: %s", ErrEventExpired, event.Time().Format(time.RFC3339))
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, t := range a.seen {
+		if now.Sub(t) > maxAge {
+			delete(a.seen, id)
+		}
+	}
+	id := event.Source() + "\n" + event.ID()
+	if _, ok := a.seen[id]; ok {
+		return fmt.Errorf("%w: %s", ErrEventReplayed, event.ID())
+	}
+	if a.seen == nil {
+		a.seen = make(map[string]time.Time)
+	}
+	a.seen[id] = event.Time()
+	return nil
+}
+
+// SignEvent signs a CloudEvent on behalf of a Party so it can be verified by a PartySignatureAuthenticator.
+// The signature covers the event id, type, source, subject, time and data. The event time is set to the
+// current time if the event has none.
+func SignEvent(event *cloudevents.Event, party string, key crypto.Signer) error {
+	alg, err := signingAlgorithm(key)
+	if err != nil {
+		return err
+	}
+	event.SetExtension(PartyExtension, party)
+	if event.Time().IsZero() {
+		event.SetTime(time.Now())
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+	if err != nil {
+		return err
+	}
+	jws, err := signer.Sign(eventSigningPayload(*event))
+	if err != nil {
+		return err
+	}
+	value, err := jws.DetachedCompactSerialize()
+	if err != nil {
+		return err
+	}
+	event.SetExtension(AuthSignatureExtension, value)
+	return nil
+}
+
+// signedEventAttributes are the attributes of a CloudEvent signed by a Party.
+type signedEventAttributes struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	Source  string `json:"source"`
+	Subject string `json:"subject"`
+	Time    string `json:"time"`
+	Party   string `json:"party"`
+	Data    string `json:"data"`
+}
+
+// eventSigningPayload returns the canonical payload of a CloudEvent signed by a Party. Binding the
+// event attributes prevents a signature from being replayed on a different event, or at another time.
+// The attributes are encoded as a JSON object, so that no attribute value can spill into another.
+func eventSigningPayload(event cloudevents.Event) []byte {
+	party, _ := event.Extensions()[PartyExtension].(string)
+	// encoding/json emits struct fields in declaration order, which makes the payload canonical.
+	payload, _ := json.Marshal(signedEventAttributes{
+		ID:      event.ID(),
+		Type:    event.Type(),
+		Source:  event.Source(),
+		Subject: event.Subject(),
+		Time:    event.Time().UTC().Format(time.RFC3339Nano),
+		Party:   party,
+		Data:    sha256Hex(event.Data()),
+	})
+	return payload
+}
+
+// authenticate verifies the event producer with the first Reconciler EventAuthenticator able to
+// verify the event credentials.
+func (r *Reconciler) authenticate(ctx context.Context, event cloudevents.Event) (*EventClaims, error) {
+	for _, a := range r.Authenticators {
+		claims, err := a.Authenticate(ctx, event)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEventUnauthenticated, err)
+		}
+		return claims, nil
+	}
+	return nil, ErrEventUnauthenticated
+}
@@ -0,0 +1,309 @@
+package slc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-jose/go-jose/v4"
+)
+
+func pemPublicKey(t *testing.T, key crypto.Signer) string {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestPartySignatureAuthenticator(t *testing.T) {
+	_, buyerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := GetFSContract("./tests/parties_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Parties[1].PublicKey = pemPublicKey(t, buyerKey)
+	a := NewPartySignatureAuthenticator(c)
+
+	tests := []struct {
+		name   string
+		key    crypto.Signer
+		party  string
+		tamper func(e *cloudevents.Event)
+		err    bool
+	}{
+		{name: "Valid signature", key: buyerKey, party: "globex"},
+		{name: "Wrong key", key: otherKey, party: "globex", err: true},
+		{name: "Party without key", key: buyerKey, party: "acme", err: true},
+		{
+			name:  "Tampered data",
+			key:   buyerKey,
+			party: "globex",
+			tamper: func(e *cloudevents.Event) {
+				_ = e.SetData(cloudevents.ApplicationJSON, map[string]any{"accepted": false})
+			},
+			err: true,
+		},
+		{
+			name:   "Replayed on another type",
+			key:    buyerKey,
+			party:  "globex",
+			tamper: func(e *cloudevents.Event) { e.SetType("com.decombine.contract.fulfilled") },
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ev, err := c.CreateEvent("com.decombine.signature.sign", "test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = ev.SetData(cloudevents.ApplicationJSON, map[string]any{"accepted": true}); err != nil {
+				t.Fatal(err)
+			}
+			if err = SignEvent(&ev, test.party, test.key); err != nil {
+				t.Fatal(err)
+			}
+			if test.tamper != nil {
+				test.tamper(&ev)
+			}
+
+			claims, err := a.Authenticate(context.Background(), ev)
+			if err != nil && !test.err {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && test.err {
+				t.Fatal("expected error, got nil")
+			}
+			if err == nil && claims.Party.ID != test.party {
+				t.Fatalf("expected party %s, got %s", test.party, claims.Party.ID)
+			}
+		})
+	}
+
+	ev, _ := c.CreateEvent("com.decombine.signature.sign", "test")
+	if _, err = a.Authenticate(context.Background(), ev); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected %v, got %v", ErrNoCredentials, err)
+	}
+
+	// Signed events are authenticated once, within the MaxAge of their time.
+	if err = SignEvent(&ev, "globex", buyerKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.Authenticate(context.Background(), ev); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err = a.Authenticate(context.Background(), ev); !errors.Is(err, ErrEventReplayed) {
+		t.Fatalf("expected %v, got %v", ErrEventReplayed, err)
+	}
+	ev, _ = c.CreateEvent("com.decombine.signature.sign", "test")
+	ev.SetTime(time.Now().Add(-time.Hour))
+	if err = SignEvent(&ev, "globex", buyerKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = a.Authenticate(context.Background(), ev); !errors.Is(err, ErrEventExpired) {
+		t.Fatalf("expected %v, got %v", ErrEventExpired, err)
+	}
+}
+
+func TestEventSigningPayload(t *testing.T) {
+	a := cloudevents.NewEvent()
+	a.SetID("1")
+	a.SetType("com.decombine.signature.sign")
+	a.SetSource("test\nx")
+	a.SetSubject("y")
+	// Attribute values holding a separator do not spill into the next attribute.
+	b := a.Clone()
+	b.SetSource("test")
+	b.SetSubject("x\ny")
+	if string(eventSigningPayload(a)) == string(eventSigningPayload(b)) {
+		t.Fatal("expected different events to have different signing payloads")
+	}
+}
+
+func TestReconcilerEventParties(t *testing.T) {
+	ctx := context.Background()
+	_, sellerKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	tests := []struct {
+		name    string
+		options []ReconcilerOptions
+		sign    bool
+		state   string
+	}{
+		// The event attributes naming the seller are not trusted without authentication.
+		{name: "Unauthenticated", state: "In Process"},
+		{name: "Unauthenticated parties", options: []ReconcilerOptions{WithUnauthenticatedParties()}, state: "Fulfilled"},
+		{name: "Authenticated", sign: true, state: "Fulfilled"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := GetFSContract("./tests/parties_ok.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Parties[0].PublicKey = pemPublicKey(t, sellerKey)
+			sm, err := NewStateMachine(ctx, "In Process", c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			options := append(test.options, WithReconcilerLogger(logger))
+			if test.sign {
+				options = append(options, WithEventAuthenticators(NewPartySignatureAuthenticator(c)))
+			}
+			r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, options...)
+
+			ev, _ := c.CreateEvent("com.decombine.contract.fulfilled", "test")
+			ev.SetExtension(PartyExtension, "acme")
+			if test.sign {
+				if err = SignEvent(&ev, "acme", sellerKey); err != nil {
+					t.Fatal(err)
+				}
+			}
+			eligible, err := r.eligibleTransitions(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = r.ConsumeEvent(ctx, &ev, eligible); err != nil {
+				t.Fatal(err)
+			}
+			if state, _ := r.FSM.State(ctx); state != test.state {
+				t.Fatalf("expected state %s, got %v", test.state, state)
+			}
+		})
+	}
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{"issuer": srv.URL, "jwks_uri": srv.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: key.Public(), KeyID: "test", Algorithm: string(jose.ES256), Use: "sig"},
+		}})
+	})
+
+	token := func(claims map[string]any) string {
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.ES256, Key: key},
+			(&jose.SignerOptions{}).WithHeader("kid", "test"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(claims)
+		jws, err := signer.Sign(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := jws.CompactSerialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+
+	c, err := GetFSContract("./tests/parties_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Network.Issuer = srv.URL
+	c.Network.ClientID = "slc"
+
+	ctx := context.Background()
+	a, err := NewOIDCAuthenticator(ctx, c, srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	tests := []struct {
+		name   string
+		claims map[string]any
+		party  string
+		err    bool
+	}{
+		{
+			name:   "Valid party token",
+			claims: map[string]any{"iss": srv.URL, "sub": "globex-service-account", "aud": []string{"slc"}, "exp": exp},
+			party:  "globex",
+		},
+		{
+			name:   "Valid token without party",
+			claims: map[string]any{"iss": srv.URL, "sub": "network-service", "aud": []string{"slc"}, "exp": exp},
+		},
+		{
+			name:   "Wrong issuer",
+			claims: map[string]any{"iss": "https://example.com", "sub": "globex-service-account", "aud": []string{"slc"}, "exp": exp},
+			err:    true,
+		},
+		{
+			name:   "Wrong audience",
+			claims: map[string]any{"iss": srv.URL, "sub": "globex-service-account", "aud": []string{"other"}, "exp": exp},
+			err:    true,
+		},
+		{
+			name:   "Expired",
+			claims: map[string]any{"iss": srv.URL, "sub": "globex-service-account", "aud": []string{"slc"}, "exp": time.Now().Add(-time.Hour).Unix()},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ev, _ := c.CreateEvent("com.decombine.signature.sign", "test")
+			ev.SetExtension(AuthTokenExtension, token(test.claims))
+
+			claims, err := a.Authenticate(ctx, ev)
+			if err != nil && !test.err {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && test.err {
+				t.Fatal("expected error, got nil")
+			}
+			if err != nil {
+				return
+			}
+			if test.party == "" && claims.Party != nil {
+				t.Fatalf("expected no party, got %s", claims.Party.ID)
+			}
+			if test.party != "" && (claims.Party == nil || claims.Party.ID != test.party) {
+				t.Fatalf("expected party %s, got %v", test.party, claims.Party)
+			}
+		})
+	}
+}
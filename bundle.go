@@ -0,0 +1,122 @@
+package slc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/open-policy-agent/opa/v1/bundle"
+	v1 "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry/remote"
+)
+
+const (
+	// MediaTypeOPABundleLayer is the media type of the layer of an OPA bundle pushed to an OCI registry.
+	MediaTypeOPABundleLayer = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	// defaultBundleAlgorithm is the signing algorithm of bundles if the BundleVerification does not declare one.
+	defaultBundleAlgorithm = "RS256"
+)
+
+var (
+	ErrBundleNotFound = errors.New("opa bundle not found")
+)
+
+// loadBundle reads the OPA bundle of a PolicySource with the OPA bundle reader, verifying its signatures with the
+// BundleVerification of the PolicySource. The data documents of the bundle are the root "data.json" document of
+// the PolicyDirectory.
+func loadBundle(ctx context.Context, source PolicySource) (*PolicyDirectory, error) {
+	reader, err := bundleReader(ctx, source.Bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve bundle %s: %w", source.Bundle, err)
+	}
+	if v := source.Verification; v != nil {
+		algorithm := v.Algorithm
+		if algorithm == "" {
+			algorithm = defaultBundleAlgorithm
+		}
+		keys := map[string]*bundle.KeyConfig{v.KeyID: {Key: v.PublicKey, Algorithm: algorithm, Scope: v.Scope}}
+		reader = reader.WithBundleVerificationConfig(bundle.NewVerificationConfig(keys, v.KeyID, v.Scope, v.Exclude))
+	}
+	b, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle %s: %w", source.Bundle, err)
+	}
+
+	d := newPolicyDirectory()
+	for _, m := range b.Modules {
+		d.Modules[policyPath(m.Path)] = m.Raw
+	}
+	if len(b.Data) > 0 {
+		data, err := json.Marshal(b.Data)
+		if err != nil {
+			return nil, err
+		}
+		d.Data[PolicyDataFile] = data
+	}
+	return d, nil
+}
+
+// bundleReader returns the bundle reader of a bundle file or directory, HTTP(S) URL or OCI reference.
+func bundleReader(ctx context.Context, location string) (*bundle.Reader, error) {
+	switch {
+	case strings.HasPrefix(location, "oci://"):
+		data, err := fetchOCIBundle(ctx, strings.TrimPrefix(location, "oci://"))
+		if err != nil {
+			return nil, err
+		}
+		return bundle.NewReader(bytes.NewReader(data)), nil
+	case strings.HasPrefix(location, "http://"), strings.HasPrefix(location, "https://"):
+		data, err := fetchDocument(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		return bundle.NewReader(bytes.NewReader(data)), nil
+	}
+
+	info, err := os.Stat(location)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return bundle.NewCustomReader(bundle.NewDirectoryLoader(location)), nil
+	}
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+	return bundle.NewReader(bytes.NewReader(data)), nil
+}
+
+// fetchOCIBundle pulls the bundle layer of an OPA bundle from an OCI registry, e.g.,
+// "ghcr.io/acme/policies:1.0.0". The registry is accessed anonymously.
+func fetchOCIBundle(ctx context.Context, reference string) ([]byte, error) {
+	repo, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, err
+	}
+	desc, rc, err := repo.FetchReference(ctx, repo.Reference.Reference)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := content.ReadAll(rc, desc)
+	if err != nil {
+		return nil, err
+	}
+	var manifest v1.Manifest
+	if err = json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType == MediaTypeOPABundleLayer {
+			return content.FetchAll(ctx, repo, layer)
+		}
+	}
+	return nil, ErrBundleNotFound
+}
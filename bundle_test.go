@@ -0,0 +1,123 @@
+package slc
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-policy-agent/opa/v1/bundle"
+)
+
+const bundlePolicy = `package order
+
+import rego.v1
+
+default allow := false
+
+allow if input.amount <= data.thresholds.max_amount
+`
+
+// signedBundle writes an OPA bundle signed with a new RSA key, and returns its path and public key.
+func signedBundle(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	private := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	public, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := bundle.Bundle{
+		Manifest: bundle.Manifest{Revision: "1"},
+		Modules:  []bundle.ModuleFile{{URL: "/order/order.rego", Path: "/order/order.rego", Raw: []byte(bundlePolicy)}},
+		Data:     map[string]interface{}{"thresholds": map[string]interface{}{"max_amount": 1000}},
+	}
+	if err = b.GenerateSignature(bundle.NewSigningConfig(string(private), "RS256", ""), "policies", true); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err = bundle.NewWriter(&buf).UseModulePath(true).DisableFormat(true).Write(b); err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "bundle.tar.gz")
+	if err = os.WriteFile(p, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return p, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: public}))
+}
+
+func TestStateMachinePolicyBundle(t *testing.T) {
+	ctx := context.Background()
+	p, publicKey := signedBundle(t)
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Policy = PolicySource{Bundle: p, Verification: &BundleVerification{KeyID: "policies", PublicKey: publicKey}}
+	c.State.States[0].Transitions[0].Conditions = []Condition{{Name: "small.order", Path: "order/order.rego", Value: "data.order.allow"}}
+
+	// The policy file and its thresholds are read from the bundle.
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []int{5000, 500} {
+		input := TransitionCtx{Input: map[string]interface{}{"amount": amount}}
+		err = sm.FireCtx(NewTransitionContext(ctx, &input), "com.decombine.order.approve")
+		if amount > 1000 && err == nil {
+			t.Fatalf("expected amount %d to be denied", amount)
+		}
+		if amount <= 1000 && err != nil {
+			t.Fatalf("expected amount %d to be allowed, got %v", amount, err)
+		}
+	}
+}
+
+func TestLoadBundle(t *testing.T) {
+	ctx := context.Background()
+	p, publicKey := signedBundle(t)
+	content, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	verification := &BundleVerification{KeyID: "policies", PublicKey: publicKey}
+	d, err := loadBundle(ctx, PolicySource{Bundle: server.URL + "/bundle.tar.gz", Verification: verification})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d.Modules["order/order.rego"]) != bundlePolicy {
+		t.Fatalf("unexpected modules %v", d.Modules)
+	}
+	documents, err := d.Documents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thresholds, _ := documents["thresholds"].(map[string]interface{}); thresholds["max_amount"] != float64(1000) {
+		t.Fatalf("unexpected documents %v", documents)
+	}
+
+	// Signed bundles are not loaded without a valid verification key.
+	if _, err = loadBundle(ctx, PolicySource{Bundle: p}); err == nil {
+		t.Fatal("expected a signed bundle without verification to fail")
+	}
+	_, otherKey := signedBundle(t)
+	if _, err = loadBundle(ctx, PolicySource{Bundle: p, Verification: &BundleVerification{KeyID: "policies", PublicKey: otherKey}}); err == nil {
+		t.Fatal("expected a bundle signed with another key to fail")
+	}
+}
@@ -0,0 +1,117 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNoFinalState          = errors.New("no final state is reachable from the initial state")
+	ErrFinalStateTransitions = errors.New("final state cannot declare transitions")
+	ErrContractCompleted     = errors.New("contract is completed")
+)
+
+// IsFinal determines if the named State is a Final State of the Contract.
+func (c *Contract) IsFinal(state string) bool {
+	s, err := c.GetState(state)
+	return err == nil && s.Final
+}
+
+// ValidateFinalStates validates that Final States declare no Transitions, and that at least one
+// Final State of the StateConfiguration is reachable from the Initial State. Regions need not declare
+// a Final State, as the SLC completes when the StateConfiguration enters one. A Contract whose Initial
+// State is not declared is rejected when constructing the FSM, so only the declaration of a Final State
+// is required.
+func (c *Contract) ValidateFinalStates() error {
+	for _, s := range c.States() {
+		if s.Final && len(s.Transitions) > 0 {
+			return fmt.Errorf("%w: %s", ErrFinalStateTransitions, s.Name)
+		}
+	}
+	declared := false
+	for _, s := range c.State.States {
+		declared = declared || s.Final
+	}
+	if !declared {
+		return ErrNoFinalState
+	}
+
+	if _, err := c.GetState(c.State.Initial); err != nil {
+		return nil
+	}
+
+	visited := make(map[string]bool)
+	queue := []string{c.State.Initial}
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+
+		s, err := c.GetState(name)
+		if err != nil {
+			continue
+		}
+		if s.Final {
+			return nil
+		}
+		transitions, _ := c.GetTransitions(name)
+		for _, t := range transitions {
+			queue = append(queue, t.To)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrNoFinalState, c.State.Initial)
+}
+
+// complete finishes the SLC after a Final State is entered and its Entry actions are reconciled. A
+// CompletedEventType event is published, and the JetStream Consumer is released. The Reconciler
+// stops consuming events once complete returns.
+func (r *Reconciler) complete(ctx context.Context, state State) error {
+	r.Logger.Info("Smart Legal Contract completed", "Contract", r.Contract.Name, "State", state.Name)
+
+	var errs []error
+	evt, err := r.Contract.CreateEvent(CompletedEventType, "decombine")
+	if err != nil {
+		errs = append(errs, err)
+	} else if err = evt.SetData("application/json", map[string]string{"state": state.Name}); err != nil {
+		errs = append(errs, err)
+	} else if r.Config.PublishSubject != "" && r.Stream != nil {
+		payload, _ := evt.MarshalJSON()
+		if _, err = r.Stream.Publish(ctx, r.Config.PublishSubject, payload); err != nil {
+			errs = append(errs, fmt.Errorf("failed to publish completed event: %w", err))
+		}
+	}
+
+	if r.done != nil {
+		r.doneOnce.Do(func() {
+			close(r.done)
+		})
+	}
+
+	if r.messages != nil {
+		r.messages.Stop()
+	}
+	if r.Consumer != nil && r.Stream != nil {
+		info := r.Consumer.CachedInfo()
+		if info != nil {
+			if err = r.Stream.DeleteConsumer(ctx, info.Stream, info.Name); err != nil {
+				errs = append(errs, fmt.Errorf("failed to release consumer: %w", err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// completed determines if the SLC is completed.
+func (r *Reconciler) completed() bool {
+	select {
+	case <-r.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,485 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	// ModelKindJSON is the Kind of Concerto models declared as a JSON AST of the Concerto metamodel, such as
+	// the models parsed from the Concerto Language (.cto) by the Concerto CLI.
+	ModelKindJSON = "json"
+)
+
+var (
+	ErrInvalidModel         = errors.New("concerto model is invalid")
+	ErrClassNotFound        = errors.New("concerto class not found")
+	ErrNonConformingPayload = errors.New("payload does not conform to concerto class")
+	ErrVariableTypeMismatch = errors.New("variable type does not match concerto property")
+)
+
+// A ModelSource is a Concerto model referenced by the Contract, declaring the classes of event data and of
+// Variables with Kind "concerto".
+type ModelSource struct {
+	// Name of the ModelSource. E.g., "reviewer-slc"
+	Name string `json:"name" yaml:"name" toml:"name"`
+	// Kind of the model. Only "json" is supported: models declared in the Concerto Language (.cto) are
+	// parsed to a JSON AST with the Concerto CLI. Defaults to the extension of the URL.
+	Kind string `json:"kind,omitempty" yaml:"kind,omitempty" toml:"kind,omitempty"`
+	// URL of the model. HTTP(S) URLs are fetched, other URLs are read from the file system.
+	// E.g., "https://models.accordproject.org/reviewer.json", "models/reviewer.json"
+	URL string `json:"url" yaml:"url" toml:"url" validate:"required"`
+}
+
+// ConcertoModels are the Concerto declarations loaded from one or more models. Declarations are identified
+// by their fully qualified name without the namespace version. E.g., "com.decombine.reviewer-slc.Reviewer"
+type ConcertoModels struct {
+	declarations map[string]*concertoDeclaration
+}
+
+// concertoDeclaration is a concept, asset, participant, transaction, event, enum or scalar declaration.
+type concertoDeclaration struct {
+	name       string
+	namespace  string
+	kind       string
+	abstract   bool
+	superType  string
+	properties []concertoProperty
+	values     []string
+	// scalar is the primitive type of a scalar declaration, and its validators.
+	scalar *concertoProperty
+	// imports resolves the short names of the model declaring the declaration.
+	imports map[string]string
+}
+
+// concertoProperty is a property of a declaration.
+type concertoProperty struct {
+	name         string
+	typ          string
+	array        bool
+	optional     bool
+	relationship bool
+	regex        *regexp.Regexp
+	lower, upper *float64
+	minLength    *int
+	maxLength    *int
+}
+
+// concertoPrimitives are the primitive types of the Concerto Language.
+var concertoPrimitives = []string{"String", "Integer", "Long", "Double", "Boolean", "DateTime"}
+
+// NewConcertoModels returns empty ConcertoModels.
+func NewConcertoModels() *ConcertoModels {
+	return &ConcertoModels{declarations: make(map[string]*concertoDeclaration)}
+}
+
+// Add parses a Concerto model of the given Kind and adds its declarations.
+func (m *ConcertoModels) Add(kind string, model []byte) error {
+	if kind != ModelKindJSON {
+		return fmt.Errorf("%w: unsupported kind %q, parse the model to a JSON AST with the Concerto CLI", ErrInvalidModel, kind)
+	}
+	declarations, err := parseConcertoAST(model)
+	if err != nil {
+		return err
+	}
+	for _, d := range declarations {
+		m.declarations[d.fqn()] = d
+	}
+	return nil
+}
+
+// LoadConcertoModels loads the Concerto models of the ModelSources. HTTP(S) URLs are fetched, and other URLs
+// are read from fsys, or from the working directory if fsys is nil.
+func LoadConcertoModels(ctx context.Context, sources []ModelSource, fsys fs.FS) (*ConcertoModels, error) {
+	if fsys == nil {
+		fsys = os.DirFS(".")
+	}
+	m := NewConcertoModels()
+	for _, src := range sources {
+		var (
+			content []byte
+			err     error
+		)
+		if strings.HasPrefix(src.URL, "http://") || strings.HasPrefix(src.URL, "https://") {
+			content, err = fetchDocument(ctx, src.URL)
+		} else {
+			content, err = fs.ReadFile(fsys, path.Clean(strings.TrimPrefix(src.URL, "file://")))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load model %s: %w", src.Name, err)
+		}
+		kind := src.Kind
+		if kind == "" {
+			kind = strings.TrimPrefix(path.Ext(src.URL), ".")
+		}
+		if err = m.Add(kind, content); err != nil {
+			return nil, fmt.Errorf("failed to load model %s: %w", src.Name, err)
+		}
+	}
+	return m, nil
+}
+
+// fetchDocument retrieves a document, such as a model or schema, over HTTP(S).
+func fetchDocument(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// unversioned removes the version from a fully qualified name or namespace.
+// E.g., "org.acme@1.0.0.Payment" yields "org.acme.Payment".
+func unversioned(name string) string {
+	at := strings.Index(name, "@")
+	if at < 0 {
+		return name
+	}
+	if dot := strings.LastIndex(name, "."); dot > at && !strings.Contains(name[at+1:dot], "@") && semver.MatchString(name[at+1:dot]) {
+		return name[:at] + name[dot:]
+	}
+	return name[:at]
+}
+
+// semver matches the version of a namespace, which also contains dots.
+var semver = regexp.MustCompile(`^\d+\.\d+\.\d+([-+].*)?$`)
+
+func (d *concertoDeclaration) fqn() string {
+	return unversioned(d.namespace) + "." + d.name
+}
+
+// Class returns the declaration of a class by its fully qualified name, with or without a namespace version.
+func (m *ConcertoModels) class(name string) (*concertoDeclaration, error) {
+	d, ok := m.declarations[unversioned(name)]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClassNotFound, name)
+	}
+	return d, nil
+}
+
+// HasClass determines if a class is declared by the models.
+func (m *ConcertoModels) HasClass(name string) bool {
+	_, err := m.class(name)
+	return err == nil
+}
+
+// resolve resolves a type name used by a declaration to a primitive type or a fully qualified name.
+func (m *ConcertoModels) resolve(d *concertoDeclaration, typ string) string {
+	if slices.Contains(concertoPrimitives, typ) {
+		return typ
+	}
+	if fqn, ok := d.imports[typ]; ok {
+		return unversioned(fqn)
+	}
+	if strings.Contains(typ, ".") {
+		return unversioned(typ)
+	}
+	local := unversioned(d.namespace) + "." + typ
+	if _, ok := m.declarations[local]; ok {
+		return local
+	}
+	// Types imported with a wildcard are resolved in any namespace.
+	for fqn := range m.declarations {
+		if strings.HasSuffix(fqn, "."+typ) {
+			return fqn
+		}
+	}
+	return local
+}
+
+// properties returns the properties of a declaration, including those of its supertypes.
+func (m *ConcertoModels) properties(d *concertoDeclaration) []concertoProperty {
+	var chain []*concertoDeclaration
+	for current := d; current != nil && !slices.Contains(chain, current); {
+		chain = append([]*concertoDeclaration{current}, chain...)
+		if current.superType == "" {
+			break
+		}
+		current, _ = m.class(m.resolve(current, current.superType))
+	}
+	var props []concertoProperty
+	for _, c := range chain {
+		props = append(props, c.properties...)
+	}
+	return props
+}
+
+// isSubclass determines if a declaration is, or extends, the given class.
+func (m *ConcertoModels) isSubclass(d *concertoDeclaration, class string) bool {
+	seen := map[*concertoDeclaration]bool{}
+	for current := d; current != nil && !seen[current]; {
+		seen[current] = true
+		if current.fqn() == unversioned(class) {
+			return true
+		}
+		if current.superType == "" {
+			return false
+		}
+		current, _ = m.class(m.resolve(current, current.superType))
+	}
+	return false
+}
+
+// Validate validates that data is an instance of the class. The "$class" of the data, if declared, must be
+// the class or one of its subclasses.
+func (m *ConcertoModels) Validate(data interface{}, class string) error {
+	d, err := m.class(class)
+	if err != nil {
+		return err
+	}
+	return m.validateObject("$", data, d)
+}
+
+// ValidatePayload validates that data is an instance of the class declared by its "$class".
+func (m *ConcertoModels) ValidatePayload(data interface{}) error {
+	obj, ok := data.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: $: expected an object", ErrNonConformingPayload)
+	}
+	class, ok := obj["$class"].(string)
+	if !ok {
+		return fmt.Errorf("%w: $: missing $class", ErrNonConformingPayload)
+	}
+	return m.Validate(data, class)
+}
+
+func (m *ConcertoModels) validateObject(at string, v interface{}, declared *concertoDeclaration) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("%w: %s: expected an object of class %s", ErrNonConformingPayload, at, declared.fqn())
+	}
+	d := declared
+	if class, ok := obj["$class"].(string); ok {
+		actual, err := m.class(class)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrNonConformingPayload, at, err)
+		}
+		if !m.isSubclass(actual, declared.fqn()) {
+			return fmt.Errorf("%w: %s: class %s is not a %s", ErrNonConformingPayload, at, class, declared.fqn())
+		}
+		d = actual
+	}
+	if d.abstract {
+		return fmt.Errorf("%w: %s: class %s is abstract", ErrNonConformingPayload, at, d.fqn())
+	}
+	if d.kind == "enum" || d.scalar != nil {
+		return fmt.Errorf("%w: %s: class %s is not a concept", ErrNonConformingPayload, at, d.fqn())
+	}
+
+	props := m.properties(d)
+	for _, p := range props {
+		value, ok := obj[p.name]
+		if !ok || value == nil {
+			if !p.optional {
+				return fmt.Errorf("%w: %s.%s: missing required property", ErrNonConformingPayload, at, p.name)
+			}
+			continue
+		}
+		if !p.array {
+			if err := m.validateValue(at+"."+p.name, value, d, p); err != nil {
+				return err
+			}
+			continue
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%w: %s.%s: expected an array", ErrNonConformingPayload, at, p.name)
+		}
+		for i, item := range items {
+			if err := m.validateValue(fmt.Sprintf("%s.%s[%d]", at, p.name, i), item, d, p); err != nil {
+				return err
+			}
+		}
+	}
+	for key := range obj {
+		if strings.HasPrefix(key, "$") {
+			continue
+		}
+		if !slices.ContainsFunc(props, func(p concertoProperty) bool { return p.name == key }) {
+			return fmt.Errorf("%w: %s.%s: property not declared by class %s", ErrNonConformingPayload, at, key, d.fqn())
+		}
+	}
+	return nil
+}
+
+func (m *ConcertoModels) validateValue(at string, v interface{}, owner *concertoDeclaration, p concertoProperty) error {
+	if p.relationship {
+		if _, ok := v.(string); !ok {
+			return fmt.Errorf("%w: %s: expected a relationship", ErrNonConformingPayload, at)
+		}
+		return nil
+	}
+	typ := m.resolve(owner, p.typ)
+	if slices.Contains(concertoPrimitives, typ) {
+		return validatePrimitive(at, v, typ, p)
+	}
+	d, err := m.class(typ)
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrNonConformingPayload, at, err)
+	}
+	switch {
+	case d.kind == "enum":
+		s, ok := v.(string)
+		if !ok || !slices.Contains(d.values, s) {
+			return fmt.Errorf("%w: %s: expected one of %v", ErrNonConformingPayload, at, d.values)
+		}
+		return nil
+	case d.scalar != nil:
+		return validatePrimitive(at, v, d.scalar.typ, *d.scalar)
+	}
+	return m.validateObject(at, v, d)
+}
+
+// validatePrimitive validates a value of a primitive type, and the validators of its property.
+func validatePrimitive(at string, v interface{}, typ string, p concertoProperty) error {
+	mismatch := fmt.Errorf("%w: %s: expected %s", ErrNonConformingPayload, at, typ)
+	switch typ {
+	case "String":
+		s, ok := v.(string)
+		if !ok {
+			return mismatch
+		}
+		if p.regex != nil && !p.regex.MatchString(s) {
+			return fmt.Errorf("%w: %s: does not match %s", ErrNonConformingPayload, at, p.regex)
+		}
+		if (p.minLength != nil && len(s) < *p.minLength) || (p.maxLength != nil && len(s) > *p.maxLength) {
+			return fmt.Errorf("%w: %s: length out of range", ErrNonConformingPayload, at)
+		}
+	case "Integer", "Long", "Double":
+		n, ok := v.(float64)
+		if !ok || (typ != "Double" && n != math.Trunc(n)) {
+			return mismatch
+		}
+		if (p.lower != nil && n < *p.lower) || (p.upper != nil && n > *p.upper) {
+			return fmt.Errorf("%w: %s: %v out of range", ErrNonConformingPayload, at, n)
+		}
+	case "Boolean":
+		if _, ok := v.(bool); !ok {
+			return mismatch
+		}
+	case "DateTime":
+		s, ok := v.(string)
+		if !ok {
+			return mismatch
+		}
+		if _, err := time.Parse(time.RFC3339, s); err != nil {
+			return mismatch
+		}
+	}
+	return nil
+}
+
+// propertyType returns the Concerto type of the property at a Variable Ref, resolving the class of the Ref
+// and walking the property path. See Variables.Resolve.
+func (m *ConcertoModels) propertyType(ref string) (string, error) {
+	for fqn, d := range m.declarations {
+		prefix := strings.ToLower(fqn) + "."
+		if !strings.HasPrefix(strings.ToLower(ref), prefix) {
+			continue
+		}
+		current := d
+		segments := strings.Split(ref[len(prefix):], ".")
+		for i, segment := range segments {
+			idx := slices.IndexFunc(m.properties(current), func(p concertoProperty) bool { return p.name == segment })
+			if idx < 0 {
+				return "", fmt.Errorf("%w: %s: property %s not declared by class %s", ErrClassNotFound, ref, segment, current.fqn())
+			}
+			p := m.properties(current)[idx]
+			typ := m.resolve(current, p.typ)
+			if i == len(segments)-1 {
+				if p.array {
+					return typ + "[]", nil
+				}
+				if p.relationship {
+					return "String", nil
+				}
+				if next, err := m.class(typ); err == nil && next.scalar != nil {
+					return next.scalar.typ, nil
+				}
+				if next, err := m.class(typ); err == nil && next.kind == "enum" {
+					return "String", nil
+				}
+				return typ, nil
+			}
+			next, err := m.class(typ)
+			if err != nil {
+				return "", err
+			}
+			current = next
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrClassNotFound, ref)
+}
+
+// variableTypes are the Variable Types compatible with each Concerto primitive type.
+var variableTypes = map[string][]string{
+	"String":   {"string"},
+	"Integer":  {"int", "integer", "number"},
+	"Long":     {"int", "integer", "long", "number"},
+	"Double":   {"float", "double", "number"},
+	"Boolean":  {"bool", "boolean"},
+	"DateTime": {"string", "datetime"},
+}
+
+// ValidateContract validates that the Transitions of the Contract declare Concerto classes of the models, and
+// that Variables with Kind "concerto" reference a property whose type matches the Variable Type.
+func (m *ConcertoModels) ValidateContract(c *Contract) error {
+	for _, s := range c.States() {
+		for _, t := range s.Transitions {
+			if t.Class != "" && !m.HasClass(t.Class) {
+				return fmt.Errorf("%w: %s (state %s, transition %s)", ErrClassNotFound, t.Class, s.Name, t.Name)
+			}
+		}
+		for _, v := range s.Variables {
+			if !strings.EqualFold(v.Kind, VariableKindConcerto) || v.Ref == "" {
+				continue
+			}
+			typ, err := m.propertyType(v.Ref)
+			if err != nil {
+				return fmt.Errorf("%w (state %s, variable %s)", err, s.Name, v.Name)
+			}
+			if v.Type != "" && !slices.Contains(variableTypes[typ], strings.ToLower(v.Type)) {
+				return fmt.Errorf("%w: %s is %s, not %s (state %s)", ErrVariableTypeMismatch, v.Name, typ, v.Type, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// conforms validates the data of an event against the Concerto class declared by the Transition it triggers.
+// Events with the MediaTypeConcertoDataV2 content type are validated against the class declared by their data.
+func (r *Reconciler) conforms(event *cloudevents.Event, t *Transition) error {
+	concerto := event.DataContentType() == MediaTypeConcertoDataV2
+	if (t == nil || t.Class == "") && !concerto {
+		return nil
+	}
+	if r.Models == nil {
+		return fmt.Errorf("%w: no concerto models loaded", ErrClassNotFound)
+	}
+	data := eventInput(event)
+	if t != nil && t.Class != "" {
+		return r.Models.Validate(data, t.Class)
+	}
+	return r.Models.ValidatePayload(data)
+}
@@ -0,0 +1,196 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+)
+
+func loadTestModels(t *testing.T) *ConcertoModels {
+	t.Helper()
+	models, err := LoadConcertoModels(context.Background(), []ModelSource{
+		{Name: "common", URL: "tests/models/common.json"},
+		{Name: "reviewer", URL: "tests/models/reviewer.json"},
+	}, os.DirFS("."))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models
+}
+
+func TestConcertoModelsValidate(t *testing.T) {
+	models := loadTestModels(t)
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"$class":      "com.decombine.reviewer-slc@1.0.0.ReviewCompleted",
+			"reviewer":    "resource:com.decombine.reviewer-slc@1.0.0.Reviewer#reviewer-42",
+			"completedAt": "2025-06-01T12:00:00Z",
+			"outcome":     "APPROVED",
+			"score":       8.0,
+			"fee":         map[string]interface{}{"value": 250.0, "currency": "USD"},
+			"comments":    []interface{}{"Looks good"},
+		}
+	}
+	with := func(key string, value interface{}) map[string]interface{} {
+		data := valid()
+		if value == nil {
+			delete(data, key)
+		} else {
+			data[key] = value
+		}
+		return data
+	}
+
+	tests := []struct {
+		name  string
+		data  interface{}
+		class string
+		err   error
+	}{
+		{name: "Conforming", data: valid(), class: "com.decombine.reviewer-slc.ReviewCompleted"},
+		{name: "Optional property omitted", data: with("fee", nil), class: "com.decombine.reviewer-slc.ReviewCompleted"},
+		{name: "Subclass of declared class", data: valid(), class: "com.decombine.reviewer-slc@1.0.0.Review"},
+		{name: "Participant", data: map[string]interface{}{"id": "reviewer-42"}, class: "com.decombine.reviewer-slc.Reviewer"},
+		{name: "Unknown class", data: valid(), class: "com.decombine.reviewer-slc.Payment", err: ErrClassNotFound},
+		{name: "Abstract class", data: with("$class", "com.decombine.reviewer-slc.Review"), class: "com.decombine.reviewer-slc.Review", err: ErrNonConformingPayload},
+		{name: "Other class", data: valid(), class: "com.decombine.reviewer-slc.Reviewer", err: ErrNonConformingPayload},
+		{name: "Required property missing", data: with("score", nil), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Inherited property missing", data: with("completedAt", nil), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Undeclared property", data: with("amount", 1.0), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Integer expected", data: with("score", 8.5), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Out of range", data: with("score", 11.0), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Enum value", data: with("outcome", "PENDING"), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "DateTime", data: with("completedAt", "yesterday"), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Array expected", data: with("comments", "Looks good"), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Imported concept", data: with("fee", map[string]interface{}{"value": 250.0, "currency": "usd"}), class: "com.decombine.reviewer-slc.ReviewCompleted", err: ErrNonConformingPayload},
+		{name: "Scalar regex", data: map[string]interface{}{"id": "42"}, class: "com.decombine.reviewer-slc.Reviewer", err: ErrNonConformingPayload},
+		{name: "Length", data: map[string]interface{}{"id": "reviewer-42", "name": ""}, class: "com.decombine.reviewer-slc.Reviewer", err: ErrNonConformingPayload},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := models.Validate(test.data, test.class)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestConcertoModelsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  string
+		model string
+	}{
+		{name: "No namespace", kind: ModelKindJSON, model: `{"declarations": []}`},
+		{name: "Malformed", kind: ModelKindJSON, model: `{"namespace": "org.acme@1.0.0", "declarations": [`},
+		{name: "Invalid regex", kind: ModelKindJSON, model: `{"namespace": "org.acme@1.0.0", "declarations": [{"$class": "concerto.metamodel@1.0.0.ConceptDeclaration", "name": "Reviewer", "properties": [{"$class": "concerto.metamodel@1.0.0.StringProperty", "name": "id", "validator": {"pattern": "["}}]}]}`},
+		{name: "Concerto Language", kind: "cto", model: "namespace org.acme@1.0.0\nconcept Reviewer {}"},
+		{name: "Unsupported declaration", kind: ModelKindJSON, model: `{"namespace": "org.acme@1.0.0", "declarations": [{"$class": "concerto.metamodel@1.0.0.Widget"}]}`},
+		{name: "Unsupported kind", kind: "xml", model: "<model/>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := NewConcertoModels().Add(test.kind, []byte(test.model)); !errors.Is(err, ErrInvalidModel) {
+				t.Fatalf("expected %v, got %v", ErrInvalidModel, err)
+			}
+		})
+	}
+}
+
+func TestConcertoModelsValidateContract(t *testing.T) {
+	models := loadTestModels(t)
+	c, err := GetFSContract("./tests/concerto_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = models.ValidateContract(c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.State.States[0].Variables[0].Type = "string"
+	if err = models.ValidateContract(c); !errors.Is(err, ErrVariableTypeMismatch) {
+		t.Fatalf("expected %v, got %v", ErrVariableTypeMismatch, err)
+	}
+	c.State.States[0].Variables[0].Type = "integer"
+
+	c.State.States[0].Variables[1].Ref = "com.decombine.reviewer-slc.reviewCompleted.reviewerId"
+	if err = models.ValidateContract(c); !errors.Is(err, ErrClassNotFound) {
+		t.Fatalf("expected %v, got %v", ErrClassNotFound, err)
+	}
+	c.State.States[0].Variables[1].Ref = "com.decombine.reviewer-slc.reviewCompleted.reviewer"
+
+	c.State.States[0].Transitions[0].Class = "com.decombine.reviewer-slc.ReviewStarted"
+	if err = models.ValidateContract(c); !errors.Is(err, ErrClassNotFound) {
+		t.Fatalf("expected %v, got %v", ErrClassNotFound, err)
+	}
+}
+
+func TestReconcilerConcerto(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/concerto_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "Review", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithReconcilerLogger(logger))
+	if r.Models, err = LoadConcertoModels(ctx, c.Models, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	consume := func(contentType string, data map[string]interface{}) {
+		evt, err := c.CreateEvent("com.decombine.review.completed", "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(data)
+		if err = evt.SetData(contentType, payload); err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+	}
+	review := map[string]interface{}{
+		"$class":      "com.decombine.reviewer-slc@1.0.0.ReviewCompleted",
+		"reviewer":    "reviewer-42",
+		"completedAt": "2025-06-01T12:00:00Z",
+		"outcome":     "APPROVED",
+		"score":       8,
+	}
+
+	// Data that does not conform to the class declared by its content type is rejected, even for
+	// Variables.
+	consume(MediaTypeConcertoDataV2, map[string]interface{}{"$class": "com.decombine.reviewer-slc.Reviewer", "id": "42"})
+	if len(c.Status.Variables) != 0 {
+		t.Fatalf("expected no variables, got %v", c.Status.Variables)
+	}
+
+	// Data that does not conform to the class of the Transition does not trigger it.
+	consume("application/json", map[string]interface{}{"score": 8})
+	if state, _ := sm.State(ctx); state != "Review" {
+		t.Fatalf("expected state Review, got %v", state)
+	}
+
+	consume(MediaTypeConcertoDataV2, review)
+	if state, _ := sm.State(ctx); state != "Reviewed" {
+		t.Fatalf("expected state Reviewed, got %v", state)
+	}
+	if c.Status.Variables["reviewScore"] != "8" || c.Status.Variables["reviewerUniqueId"] != "reviewer-42" {
+		t.Fatalf("unexpected variables: %v", c.Status.Variables)
+	}
+}
@@ -0,0 +1,325 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+)
+
+var (
+	ErrInvalidCondition = errors.New("condition is invalid")
+)
+
+// conditionGuard evaluates the guard Conditions of the Transitions of a Contract.
+type conditionGuard struct {
+	contract   *Contract
+	options    *FSMOptions
+	evaluators map[string]ConditionEvaluator
+	logger     *slog.Logger
+
+	// mu guards the policy directory and data documents, which are loaded once successfully. Failed loads
+	// are retried by the next evaluation.
+	mu        sync.Mutex
+	directory *PolicyDirectory
+	documents map[string]interface{}
+
+	// pinned are the policy files of Conditions by Path, loaded once when the Signatures of the Contract are
+	// verified, so that the policies evaluated are those covered by the Signatures.
+	pinned map[string][]byte
+}
+
+// digestContent loads the policy files of the Conditions and the TextSources of the Contract, and returns them
+// as DigestOptions along with the policy files by Path.
+func (g *conditionGuard) digestContent(ctx context.Context) ([]DigestOption, map[string][]byte, error) {
+	var opts []DigestOption
+	policies := make(map[string][]byte)
+	for _, s := range g.contract.States() {
+		for _, t := range s.Transitions {
+			for _, top := range t.Conditions {
+				for _, cond := range top.flatten() {
+					if cond.Path == "" || cond.Rego != "" {
+						continue
+					}
+					if _, ok := policies[cond.Path]; ok {
+						continue
+					}
+					policies[cond.Path] = g.policyContent(ctx, cond)
+					opts = append(opts, WithPolicyContent(cond.Path, policies[cond.Path]))
+				}
+			}
+		}
+	}
+	if len(g.contract.Text.Sources) > 0 {
+		documents, err := g.contract.FetchText(ctx, WithGitHubPAT(g.options.GitHubPAT))
+		if err != nil {
+			return nil, nil, err
+		}
+		opts = append(opts, WithTextContent(textDigestContent(documents)))
+	}
+	return opts, policies, nil
+}
+
+// policies returns the policy directory of the Contract, loaded on first success.
+func (g *conditionGuard) policies(ctx context.Context) (*PolicyDirectory, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.loadPolicies(ctx)
+}
+
+func (g *conditionGuard) loadPolicies(ctx context.Context) (*PolicyDirectory, error) {
+	if g.directory != nil {
+		return g.directory, nil
+	}
+	d, err := loadPolicies(ctx, g.contract.Policy, g.options)
+	if err != nil {
+		return nil, err
+	}
+	g.directory = d
+	return d, nil
+}
+
+// directoryData determines if the data documents of the policy directory are available to policies: those of
+// the FilesystemPath of the FSMOptions or of the Bundle, and those of the Git directory when Conditions
+// reference its policy files, so that Contracts with only inline policies do not download the directory.
+func (g *conditionGuard) directoryData() bool {
+	if g.options.FilesystemPath != "" || g.contract.Policy.Bundle != "" {
+		return true
+	}
+	if g.contract.Policy.Directory == "" {
+		return false
+	}
+	for _, s := range g.contract.States() {
+		for _, t := range s.Transitions {
+			for _, top := range t.Conditions {
+				for _, cond := range top.flatten() {
+					if cond.Path != "" {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// data returns the data documents of the policies of the Contract, loaded on first success: the data documents
+// of its policy directory, if available, merged with its data files and the policy data of the FSMOptions.
+func (g *conditionGuard) data(ctx context.Context) (map[string]interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.documents != nil {
+		return g.documents, nil
+	}
+	documents := make(map[string]interface{})
+	if g.directoryData() {
+		d, err := g.loadPolicies(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if documents, err = d.Documents(); err != nil {
+			return nil, err
+		}
+	}
+	documents, err := policyData(ctx, documents, g.contract.Policy, g.options)
+	if err != nil {
+		return nil, err
+	}
+	g.documents = documents
+	return documents, nil
+}
+
+// evaluate determines if a Condition of a Transition, including its nested Conditions, is satisfied for the
+// TransitionCtx. A Condition without a policy is satisfied by its Roles and nested Conditions alone. The
+// evaluation of each policy is logged as a PolicyDecision.
+func (g *conditionGuard) evaluate(ctx context.Context, state, transition string, condition Condition, tCtx *TransitionCtx, vars []Variables) bool {
+	// Conditions restricted to Roles require an acting Party holding one of them.
+	if len(condition.Roles) > 0 && !tCtx.Party.HasRole(condition.Roles...) {
+		g.logger.Debug("Acting party does not hold a required role", "condition", condition.Name, "roles", condition.Roles)
+		return false
+	}
+
+	for _, sub := range condition.AllOf {
+		if !g.evaluate(ctx, state, transition, sub, tCtx, vars) {
+			return false
+		}
+	}
+	if len(condition.AnyOf) > 0 {
+		satisfied := false
+		for _, sub := range condition.AnyOf {
+			if g.evaluate(ctx, state, transition, sub, tCtx, vars) {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	if condition.Not != nil && g.evaluate(ctx, state, transition, *condition.Not, tCtx, vars) {
+		return false
+	}
+
+	if !condition.hasPolicy() {
+		return true
+	}
+	policyContent := g.policyContent(ctx, condition)
+
+	g.logger.Debug("Policy file content", "path", condition.Path, "content", string(policyContent))
+
+	start := time.Now()
+	input := tCtx.guardInput()
+	allowed, err := g.evaluators[condition.engine()].Evaluate(ctx, condition, policyContent, input, vars)
+	decision := PolicyDecision{
+		Time:       start.UTC(),
+		State:      state,
+		Transition: transition,
+		Condition:  condition.Name,
+		Engine:     condition.engine(),
+		Query:      condition.Value,
+		Allowed:    allowed && err == nil,
+		Duration:   time.Since(start),
+	}
+	if err != nil {
+		decision.Error = err.Error()
+	}
+	g.logDecision(ctx, decision, policyContent, input, tCtx)
+	if err != nil {
+		g.logger.Error("Error evaluating condition", "condition", condition.Name, "engine", condition.engine(), "error", err)
+		return false
+	}
+	return allowed
+}
+
+// policyContent returns the inline policy of a Condition, or retrieves its policy file.
+func (g *conditionGuard) policyContent(ctx context.Context, condition Condition) []byte {
+	if condition.Rego != "" {
+		return []byte(condition.Rego)
+	}
+	if condition.engine() != EngineRego && condition.Path == "" {
+		return []byte(condition.Value)
+	}
+	if content, ok := g.pinned[condition.Path]; ok {
+		return content
+	}
+
+	var policyContent []byte
+
+	// Retrieve any policy files that will be required for the FSM to evaluate transitions.
+	// For now, these are loaded into memory on each evaluation, which is not ideal,
+	// but it simplifies the scenario for now. This should be refactored to load the policy files
+	// when constructing the FSM, but that will then require a mapping between policy files and conditions.
+	// TODO: This may have a large memory footprint; profiling should be done in various scenarios when feasible.
+	// TODO: Refactor this to a cleaner implementation.
+	if g.options.FilesystemPath != "" {
+
+		// Ensure the path ends with a trailing slash to avoid policy files not being found.
+		if g.options.FilesystemPath[len(g.options.FilesystemPath)-1:] != "/" {
+			g.options.FilesystemPath += "/"
+		}
+
+		policyContent, _ = os.ReadFile(g.options.FilesystemPath + condition.Path)
+	} else if module := g.directoryModule(ctx, condition.Path); module != nil {
+		policyContent = module
+	} else if g.options.GitHubPAT != "" {
+		policyContent, _ = getPolicyFile(ctx, g.contract.Policy.URL, g.contract.Policy.Branch, g.options.GitHubPAT, condition.Path)
+	} else {
+		policyContent, _ = getPolicyFile(ctx, g.contract.Policy.URL, g.contract.Policy.Branch, "", condition.Path)
+	}
+	return policyContent
+}
+
+// directoryModule returns the policy file of the policy directory of the Contract at a path, if the Contract
+// declares a policy directory or Bundle. The policy directory is cached once downloaded.
+func (g *conditionGuard) directoryModule(ctx context.Context, p string) []byte {
+	if g.contract.Policy.Directory == "" && g.contract.Policy.Bundle == "" {
+		return nil
+	}
+	d, err := g.policies(ctx)
+	if err != nil {
+		return nil
+	}
+	return d.Modules[policyPath(p)]
+}
+
+// hasPolicy determines if the Condition declares a policy, either as a file or inline.
+func (c Condition) hasPolicy() bool {
+	return c.Path != "" || c.Value != "" || c.Rego != ""
+}
+
+// flatten returns the Condition followed by its nested Conditions, depth first.
+func (c Condition) flatten() []Condition {
+	conditions := []Condition{c}
+	for _, sub := range c.AllOf {
+		conditions = append(conditions, sub.flatten()...)
+	}
+	for _, sub := range c.AnyOf {
+		conditions = append(conditions, sub.flatten()...)
+	}
+	if c.Not != nil {
+		conditions = append(conditions, c.Not.flatten()...)
+	}
+	return conditions
+}
+
+// ValidateConditions validates that every Condition, including nested Conditions, declares a policy, Roles or
+// nested Conditions, that inline Rego is declared in place of a policy file with a query to evaluate, and
+// that inline CEL expressions and JSONLogic rules are well-formed.
+func (c *Contract) ValidateConditions() error {
+	for _, s := range c.States() {
+		for _, t := range s.Transitions {
+			for _, top := range t.Conditions {
+				for _, cond := range top.flatten() {
+					if err := cond.validate(); err != nil {
+						return fmt.Errorf("%w (state %s, transition %s)", err, s.Name, t.Name)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validate validates the Condition, excluding its nested Conditions.
+func (c Condition) validate() error {
+	if !c.hasPolicy() && len(c.Roles) == 0 && len(c.AllOf) == 0 && len(c.AnyOf) == 0 && c.Not == nil {
+		return fmt.Errorf("%w: %s: no policy, roles or nested conditions", ErrInvalidCondition, c.Name)
+	}
+	if c.Rego != "" && c.engine() != EngineRego {
+		return fmt.Errorf("%w: %s: inline rego requires the rego engine", ErrInvalidCondition, c.Name)
+	}
+	if c.Path == "" && c.Value != "" {
+		switch c.engine() {
+		case EngineCEL:
+			env, err := celEnv()
+			if err != nil {
+				return err
+			}
+			if _, iss := env.Compile(c.Value); iss.Err() != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidCondition, c.Name, iss.Err())
+			}
+		case EngineJSONLogic:
+			if _, err := parseJSONLogic([]byte(c.Value)); err != nil {
+				return fmt.Errorf("%w: %s: %v", ErrInvalidCondition, c.Name, err)
+			}
+		}
+	}
+	if c.Rego == "" {
+		return nil
+	}
+	if c.Path != "" {
+		return fmt.Errorf("%w: %s: rego and path are mutually exclusive", ErrInvalidCondition, c.Name)
+	}
+	if c.Value == "" {
+		return fmt.Errorf("%w: %s: inline rego requires a value query", ErrInvalidCondition, c.Name)
+	}
+	if _, err := ast.ParseModule(c.Name, c.Rego); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidCondition, c.Name, err)
+	}
+	return nil
+}
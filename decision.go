@@ -0,0 +1,158 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// redacted replaces the redacted fields of the guard input of PolicyDecisions.
+const redacted = "[REDACTED]"
+
+// PolicyDecision records the evaluation of the policy of a guard Condition, to show why a Transition was allowed
+// or denied. PolicyDecisions are logged with a DecisionLogger, and their IDs are included in the AuditEntry of the
+// Transition.
+type PolicyDecision struct {
+	// The unique identifier (UUID) of the PolicyDecision
+	ID string `json:"id"`
+	// Time the Condition was evaluated
+	Time time.Time `json:"time"`
+	// The ID of the Contract
+	Contract string `json:"contract,omitempty"`
+	// The State declaring the Transition
+	State string `json:"state"`
+	// The Transition guarded by the Condition
+	Transition string `json:"transition"`
+	// The Condition evaluated
+	Condition string `json:"condition"`
+	// The Engine evaluating the Condition. E.g., "rego"
+	Engine string `json:"engine"`
+	// The Query of the Condition, its Value. E.g., "data.order.allow"
+	Query string `json:"query,omitempty"`
+	// PolicyDigest is the digest of the policy evaluated. E.g., "sha256:9f86d0..."
+	PolicyDigest string `json:"policyDigest"`
+	// InputDigest is the digest of the JSON guard input.
+	InputDigest string `json:"inputDigest"`
+	// Input is the guard input with its redacted fields, if enabled with WithDecisionInput.
+	Input interface{} `json:"input,omitempty"`
+	// Allowed indicates the Condition is satisfied.
+	Allowed bool `json:"allowed"`
+	// Error evaluating the Condition, if any. Conditions that cannot be evaluated are not satisfied.
+	Error string `json:"error,omitempty"`
+	// Duration of the evaluation
+	Duration time.Duration `json:"duration"`
+}
+
+// A DecisionLogger records the PolicyDecisions of guard Conditions.
+type DecisionLogger interface {
+	LogDecision(ctx context.Context, decision PolicyDecision) error
+}
+
+// SlogDecisionLogger is a DecisionLogger logging PolicyDecisions with a slog.Logger.
+type SlogDecisionLogger struct {
+	logger *slog.Logger
+}
+
+// NewSlogDecisionLogger creates a SlogDecisionLogger logging PolicyDecisions at the Info level.
+func NewSlogDecisionLogger(logger *slog.Logger) *SlogDecisionLogger {
+	return &SlogDecisionLogger{logger: logger}
+}
+
+func (l *SlogDecisionLogger) LogDecision(ctx context.Context, d PolicyDecision) error {
+	attrs := []slog.Attr{
+		slog.String("id", d.ID),
+		slog.String("contract", d.Contract),
+		slog.String("state", d.State),
+		slog.String("transition", d.Transition),
+		slog.String("condition", d.Condition),
+		slog.String("engine", d.Engine),
+		slog.String("query", d.Query),
+		slog.String("policyDigest", d.PolicyDigest),
+		slog.String("inputDigest", d.InputDigest),
+		slog.Bool("allowed", d.Allowed),
+		slog.Duration("duration", d.Duration),
+	}
+	if d.Input != nil {
+		attrs = append(attrs, slog.Any("input", d.Input))
+	}
+	if d.Error != "" {
+		attrs = append(attrs, slog.String("error", d.Error))
+	}
+	l.logger.LogAttrs(ctx, slog.LevelInfo, "Policy decision", attrs...)
+	return nil
+}
+
+// JetStreamDecisionLogger is a DecisionLogger publishing PolicyDecisions as JSON to a JetStream subject. The
+// ID of the PolicyDecision is the message ID, so that retried publications are deduplicated.
+type JetStreamDecisionLogger struct {
+	stream  jetstream.JetStream
+	subject string
+}
+
+// NewJetStreamDecisionLogger creates a JetStreamDecisionLogger publishing to the given subject. E.g.,
+// "decombine.decisions"
+func NewJetStreamDecisionLogger(stream jetstream.JetStream, subject string) *JetStreamDecisionLogger {
+	return &JetStreamDecisionLogger{stream: stream, subject: subject}
+}
+
+func (l *JetStreamDecisionLogger) LogDecision(ctx context.Context, d PolicyDecision) error {
+	payload, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	_, err = l.stream.Publish(ctx, l.subject, payload, jetstream.WithMsgID(d.ID))
+	return err
+}
+
+// logDecision logs the PolicyDecision of the policy of a Condition with the DecisionLogger of the FSMOptions, if
+// any, and records its ID in the TransitionCtx.
+func (g *conditionGuard) logDecision(ctx context.Context, d PolicyDecision, policy []byte, input interface{}, tCtx *TransitionCtx) {
+	if g.options.DecisionLogger == nil {
+		return
+	}
+	d.ID = uuid.New().String()
+	d.Contract = g.contract.ID
+	d.PolicyDigest = DigestAlgorithm + ":" + sha256Hex(policy)
+	content, err := json.Marshal(input)
+	if err != nil {
+		g.logger.Error("Error encoding guard input", "condition", d.Condition, "error", err)
+	}
+	d.InputDigest = DigestAlgorithm + ":" + sha256Hex(content)
+	if g.options.DecisionInput {
+		d.Input = redactInput(content, g.options.RedactInput)
+	}
+
+	if err = g.options.DecisionLogger.LogDecision(ctx, d); err != nil {
+		g.logger.Error("Error logging policy decision", "condition", d.Condition, "decision", d.ID, "error", err)
+		return
+	}
+	tCtx.Decisions = append(tCtx.Decisions, d.ID)
+}
+
+// redactInput decodes a JSON guard input, replacing the values of the given dot-separated field paths. E.g.,
+// "claims" or "payment.card"
+func redactInput(content []byte, paths []string) interface{} {
+	var input interface{}
+	if err := json.Unmarshal(content, &input); err != nil {
+		return nil
+	}
+	for _, p := range paths {
+		fields := strings.Split(p, ".")
+		object, ok := input.(map[string]interface{})
+		for _, field := range fields[:len(fields)-1] {
+			if !ok {
+				break
+			}
+			object, ok = object[field].(map[string]interface{})
+		}
+		if _, found := object[fields[len(fields)-1]]; ok && found {
+			object[fields[len(fields)-1]] = redacted
+		}
+	}
+	return input
+}
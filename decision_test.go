@@ -0,0 +1,116 @@
+package slc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordedDecisions records the PolicyDecisions logged.
+type recordedDecisions struct {
+	decisions []PolicyDecision
+}
+
+func (r *recordedDecisions) LogDecision(_ context.Context, d PolicyDecision) error {
+	r.decisions = append(r.decisions, d)
+	return nil
+}
+
+func TestDecisionLogger(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Parties = []Party{{ID: "buyer", Role: "buyer"}}
+	policy := "package order\n\nallow if input.amount <= 1000"
+	c.State.States[0].Transitions[0].Conditions = []Condition{{Name: "small.order", Rego: policy, Value: "data.order.allow"}}
+
+	recorded := &recordedDecisions{}
+	sm, err := NewStateMachine(ctx, "Draft", c, WithDecisionLogger(recorded), WithDecisionInput("card", "party.role"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithReconcilerLogger(logger), WithUnauthenticatedParties())
+
+	for _, amount := range []int{5000, 500} {
+		event := simulationEvent(t, c, "com.decombine.order.approve", "buyer", map[string]interface{}{"amount": amount, "card": "4111"})
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &event, eligible); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(recorded.decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %v", recorded.decisions)
+	}
+	denied, allowed := recorded.decisions[0], recorded.decisions[1]
+	if denied.Allowed || !allowed.Allowed {
+		t.Fatalf("unexpected decisions %+v", recorded.decisions)
+	}
+	if allowed.State != "Draft" || allowed.Transition != "Approval" || allowed.Condition != "small.order" ||
+		allowed.Engine != EngineRego || allowed.Query != "data.order.allow" {
+		t.Fatalf("unexpected decision %+v", allowed)
+	}
+	if allowed.PolicyDigest != "sha256:"+sha256Hex([]byte(policy)) {
+		t.Fatalf("unexpected policy digest %s", allowed.PolicyDigest)
+	}
+	if allowed.InputDigest == denied.InputDigest || !strings.HasPrefix(allowed.InputDigest, "sha256:") {
+		t.Fatalf("unexpected input digests %s, %s", denied.InputDigest, allowed.InputDigest)
+	}
+	input, _ := json.Marshal(allowed.Input)
+	if expected := `{"amount":500,"card":"[REDACTED]","party":{"id":"buyer","role":"[REDACTED]"}}`; string(input) != expected {
+		t.Fatalf("expected input %s, got %s", expected, input)
+	}
+
+	// Only the fired Transition is audited, with the decision allowing it.
+	if len(c.Status.Audit) != 1 {
+		t.Fatalf("expected 1 audit entry, got %v", c.Status.Audit)
+	}
+	entry := c.Status.Audit[0]
+	if entry.Type != AuditTransition || entry.From != "Draft" || entry.To != "Approved" ||
+		!equalSlices(entry.Parties, []string{"buyer"}) || !equalSlices(entry.Decisions, []string{allowed.ID}) {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+}
+
+func TestDecisionLoggers(t *testing.T) {
+	ctx := context.Background()
+	d := PolicyDecision{ID: "d1", Condition: "small.order", Allowed: true, Duration: time.Millisecond}
+
+	var buf bytes.Buffer
+	if err := NewSlogDecisionLogger(slog.New(slog.NewJSONHandler(&buf, nil))).LogDecision(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["msg"] != "Policy decision" || record["id"] != "d1" || record["allowed"] != true {
+		t.Fatalf("unexpected record %v", record)
+	}
+
+	stream := &publishedStream{}
+	if err := NewJetStreamDecisionLogger(stream, "decombine.decisions").LogDecision(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+	var published PolicyDecision
+	if len(stream.published) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(stream.published))
+	}
+	if err := json.Unmarshal(stream.published[0], &published); err != nil {
+		t.Fatal(err)
+	}
+	if published.ID != d.ID || published.Condition != d.Condition || published.Duration != d.Duration {
+		t.Fatalf("unexpected decision %+v", published)
+	}
+}
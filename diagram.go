@@ -0,0 +1,310 @@
+package slc
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// currentStateColor is the fill color of the current States in diagrams.
+const currentStateColor = "#fff3b0"
+
+// diagram identifies the States of a Contract for rendering, as State names may contain spaces and dots.
+// States are identified as "s0", "s1", ... in the order of Contract.States, and Regions as "r0", "r1", ...
+type diagram struct {
+	c        *Contract
+	ids      map[string]string
+	children map[string][]State
+	current  map[string]bool
+}
+
+// diagramScope is the StateConfiguration, or one of its Regions.
+type diagramScope struct {
+	id      string
+	name    string
+	initial string
+	states  []State
+}
+
+// diagramEdge is a Transition between two States.
+type diagramEdge struct {
+	from  string
+	to    string
+	label string
+}
+
+func (c *Contract) diagram() diagram {
+	d := diagram{
+		c:        c,
+		ids:      make(map[string]string),
+		children: make(map[string][]State),
+		current:  make(map[string]bool),
+	}
+	for i, s := range c.States() {
+		d.ids[s.Name] = fmt.Sprintf("s%d", i)
+	}
+	for _, s := range c.States() {
+		if _, ok := d.ids[s.Parent]; ok {
+			d.children[s.Parent] = append(d.children[s.Parent], s)
+		}
+	}
+	for _, s := range c.Status.CurrentState {
+		d.current[s] = true
+	}
+	return d
+}
+
+func (d diagram) scopes() []diagramScope {
+	scopes := []diagramScope{{initial: d.c.State.Initial, states: d.c.State.States}}
+	for i, r := range d.c.State.Regions {
+		scopes = append(scopes, diagramScope{id: fmt.Sprintf("r%d", i), name: r.Name, initial: r.Initial, states: r.States})
+	}
+	return scopes
+}
+
+// topLevel returns the States of the scope that are not nested within a superstate.
+func (d diagram) topLevel(scope diagramScope) []State {
+	var states []State
+	for _, s := range scope.states {
+		if _, ok := d.ids[s.Parent]; !ok {
+			states = append(states, s)
+		}
+	}
+	return states
+}
+
+// edges returns the Transitions declared by a State. Transitions to undeclared States are omitted.
+func (d diagram) edges(s State) []diagramEdge {
+	var edges []diagramEdge
+	for _, t := range s.Transitions {
+		if to, ok := d.ids[t.To]; ok {
+			edges = append(edges, diagramEdge{from: d.ids[s.Name], to: to, label: transitionLabel(t)})
+		}
+	}
+	return edges
+}
+
+// transitionLabel labels a Transition with its trigger and the names of its Conditions. E.g.,
+// "com.decombine.signature.sign [signed, approved]", "com.decombine.payment.overdue after 72h"
+func transitionLabel(t Transition) string {
+	label := t.On
+	switch {
+	case t.After != "":
+		label += " after " + t.After
+	case t.At != "":
+		label += " at " + t.At
+	case len(t.Join) > 0:
+		var join []string
+		for region, state := range t.Join {
+			join = append(join, region+"="+state)
+		}
+		slices.Sort(join)
+		label += " join " + strings.Join(join, ", ")
+	}
+	if len(t.Conditions) > 0 {
+		names := make([]string, len(t.Conditions))
+		for i, c := range t.Conditions {
+			names[i] = c.Name
+		}
+		label += " [" + strings.Join(names, ", ") + "]"
+	}
+	if t.Internal {
+		label += " (internal)"
+	}
+	return label
+}
+
+// ToDOT renders the state machine of the Contract as a Graphviz DOT digraph. Edges are labelled with the
+// trigger and Condition names of their Transition, Final States are outlined twice, and the current States
+// are highlighted. Superstates and Regions are rendered as clusters.
+func (c *Contract) ToDOT() string {
+	d := c.diagram()
+	var b strings.Builder
+	fmt.Fprintf(&b, "digraph %s {\n", dotQuote(c.Name))
+	b.WriteString("\tcompound=true;\n\trankdir=LR;\n\tnode [shape=box, style=rounded];\n")
+
+	var edges []diagramEdge
+	for _, scope := range d.scopes() {
+		indent, initial := "\t", "initial"
+		if scope.id != "" {
+			fmt.Fprintf(&b, "\tsubgraph cluster_%s {\n\t\tlabel=%s;\n", scope.id, dotQuote(scope.name))
+			indent, initial = "\t\t", scope.id+"_initial"
+		}
+		fmt.Fprintf(&b, "%s%s [shape=point];\n", indent, initial)
+		if id, ok := d.ids[scope.initial]; ok {
+			edges = append(edges, diagramEdge{from: initial, to: id})
+		}
+		for _, s := range d.topLevel(scope) {
+			d.writeDOTState(&b, s, indent)
+		}
+		if scope.id != "" {
+			b.WriteString("\t}\n")
+		}
+		for _, s := range scope.states {
+			edges = append(edges, d.edges(s)...)
+		}
+	}
+
+	clusters := make(map[string]bool)
+	for name := range d.children {
+		clusters[d.ids[name]] = true
+	}
+	for _, e := range edges {
+		var attrs []string
+		if e.label != "" {
+			attrs = append(attrs, "label="+dotQuote(e.label))
+		}
+		if clusters[e.from] {
+			attrs = append(attrs, "ltail=cluster_"+e.from)
+		}
+		if clusters[e.to] && e.to != e.from {
+			attrs = append(attrs, "lhead=cluster_"+e.to)
+		}
+		if len(attrs) == 0 {
+			fmt.Fprintf(&b, "\t%s -> %s;\n", e.from, e.to)
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s -> %s [%s];\n", e.from, e.to, strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (d diagram) writeDOTState(b *strings.Builder, s State, indent string) {
+	id := d.ids[s.Name]
+	children := d.children[s.Name]
+	if len(children) == 0 {
+		attrs := []string{"label=" + dotQuote(s.Name)}
+		if s.Final {
+			attrs = append(attrs, "peripheries=2")
+		}
+		if d.current[s.Name] {
+			attrs = append(attrs, `style="rounded,filled"`, "fillcolor="+dotQuote(currentStateColor))
+		}
+		fmt.Fprintf(b, "%s%s [%s];\n", indent, id, strings.Join(attrs, ", "))
+		return
+	}
+
+	// A superstate is a cluster of its substates, with an invisible node as the endpoint of its Transitions.
+	fmt.Fprintf(b, "%ssubgraph cluster_%s {\n", indent, id)
+	fmt.Fprintf(b, "%s\tlabel=%s;\n", indent, dotQuote(s.Name))
+	if d.current[s.Name] {
+		fmt.Fprintf(b, "%s\tstyle=\"rounded,filled\";\n%s\tfillcolor=%s;\n", indent, indent, dotQuote(currentStateColor))
+	}
+	fmt.Fprintf(b, "%s\t%s [shape=point, style=invis];\n", indent, id)
+	for _, child := range children {
+		d.writeDOTState(b, child, indent+"\t")
+	}
+	fmt.Fprintf(b, "%s}\n", indent)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// ToMermaid renders the state machine of the Contract as a Mermaid state diagram. Edges are labelled with the
+// trigger and Condition names of their Transition, Final States transition to the final pseudostate, and the
+// current States are highlighted. Superstates and Regions are rendered as composite States.
+func (c *Contract) ToMermaid() string {
+	d := c.diagram()
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	for _, scope := range d.scopes() {
+		if scope.id == "" {
+			d.writeMermaidStates(&b, d.topLevel(scope), scope.initial, "    ")
+			continue
+		}
+		fmt.Fprintf(&b, "    state \"%s\" as %s\n    state %s {\n", diagramQuote(scope.name), scope.id, scope.id)
+		d.writeMermaidStates(&b, d.topLevel(scope), scope.initial, "        ")
+		b.WriteString("    }\n")
+	}
+
+	var current []string
+	for _, s := range c.States() {
+		if d.current[s.Name] {
+			current = append(current, d.ids[s.Name])
+		}
+	}
+	if len(current) > 0 {
+		fmt.Fprintf(&b, "    classDef current fill:%s,stroke-width:2px\n", currentStateColor)
+		fmt.Fprintf(&b, "    class %s current\n", strings.Join(current, ","))
+	}
+	return b.String()
+}
+
+func (d diagram) writeMermaidStates(b *strings.Builder, states []State, initial, indent string) {
+	for _, s := range states {
+		id := d.ids[s.Name]
+		fmt.Fprintf(b, "%sstate \"%s\" as %s\n", indent, diagramQuote(s.Name), id)
+		if children := d.children[s.Name]; len(children) > 0 {
+			fmt.Fprintf(b, "%sstate %s {\n", indent, id)
+			d.writeMermaidStates(b, children, "", indent+"    ")
+			fmt.Fprintf(b, "%s}\n", indent)
+		}
+	}
+	if id, ok := d.ids[initial]; ok {
+		fmt.Fprintf(b, "%s[*] --> %s\n", indent, id)
+	}
+	for _, s := range states {
+		for _, e := range d.edges(s) {
+			fmt.Fprintf(b, "%s%s --> %s : %s\n", indent, e.from, e.to, e.label)
+		}
+		if s.Final {
+			fmt.Fprintf(b, "%s%s --> [*]\n", indent, d.ids[s.Name])
+		}
+	}
+}
+
+// ToPlantUML renders the state machine of the Contract as a PlantUML state diagram. Edges are labelled with the
+// trigger and Condition names of their Transition, Final States transition to the final pseudostate, and the
+// current States are highlighted. Superstates and Regions are rendered as composite States.
+func (c *Contract) ToPlantUML() string {
+	d := c.diagram()
+	var b strings.Builder
+	b.WriteString("@startuml\nhide empty description\n")
+	for _, scope := range d.scopes() {
+		if scope.id == "" {
+			d.writePlantUMLStates(&b, d.topLevel(scope), scope.initial, "")
+			continue
+		}
+		fmt.Fprintf(&b, "state \"%s\" as %s {\n", diagramQuote(scope.name), scope.id)
+		d.writePlantUMLStates(&b, d.topLevel(scope), scope.initial, "  ")
+		b.WriteString("}\n")
+	}
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+func (d diagram) writePlantUMLStates(b *strings.Builder, states []State, initial, indent string) {
+	for _, s := range states {
+		id := d.ids[s.Name]
+		fmt.Fprintf(b, "%sstate \"%s\" as %s", indent, diagramQuote(s.Name), id)
+		if d.current[s.Name] {
+			b.WriteString(" " + currentStateColor)
+		}
+		children := d.children[s.Name]
+		if len(children) == 0 {
+			b.WriteString("\n")
+			continue
+		}
+		b.WriteString(" {\n")
+		d.writePlantUMLStates(b, children, "", indent+"  ")
+		fmt.Fprintf(b, "%s}\n", indent)
+	}
+	if id, ok := d.ids[initial]; ok {
+		fmt.Fprintf(b, "%s[*] --> %s\n", indent, id)
+	}
+	for _, s := range states {
+		for _, e := range d.edges(s) {
+			fmt.Fprintf(b, "%s%s --> %s : %s\n", indent, e.from, e.to, e.label)
+		}
+		if s.Final {
+			fmt.Fprintf(b, "%s%s --> [*]\n", indent, d.ids[s.Name])
+		}
+	}
+}
+
+// diagramQuote replaces the double quotes of State and Region names, which Mermaid and PlantUML cannot escape.
+func diagramQuote(s string) string {
+	return strings.ReplaceAll(s, `"`, "'")
+}
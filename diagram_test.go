@@ -0,0 +1,122 @@
+package slc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiagrams(t *testing.T) {
+	c, err := GetFSContract("./tests/regions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Status.CurrentState = []string{"Active", "Paid", "Shipped"}
+
+	tests := []struct {
+		name     string
+		render   func() string
+		expected []string
+	}{
+		{
+			name:   "DOT",
+			render: c.ToDOT,
+			expected: []string{
+				`digraph "My Contract" {`,
+				"initial -> s0;",
+				`s1 [label="Active", style="rounded,filled", fillcolor="#fff3b0"];`,
+				`s2 [label="Fulfilled", peripheries=2];`,
+				"subgraph cluster_r0 {",
+				`s1 -> s2 [label="com.decombine.contract.settle join Delivery=Delivered, Payment=Paid"];`,
+				"r1_initial -> s5;",
+			},
+		},
+		{
+			name:   "Mermaid",
+			render: c.ToMermaid,
+			expected: []string{
+				"stateDiagram-v2\n",
+				"[*] --> s0",
+				"s0 --> s1 : com.decombine.signature.sign",
+				"s2 --> [*]",
+				"state r0 {\n        state \"Unpaid\" as s3",
+				"class s1,s4,s6 current",
+			},
+		},
+		{
+			name:   "PlantUML",
+			render: c.ToPlantUML,
+			expected: []string{
+				"@startuml\n",
+				"state \"Active\" as s1 #fff3b0\n",
+				"state \"Payment\" as r0 {\n  state \"Unpaid\" as s3\n",
+				"  s5 --> s6 : com.decombine.delivery.ship\n",
+				"s2 --> [*]",
+				"@enduml\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diagram := tt.render()
+			for _, s := range tt.expected {
+				if !strings.Contains(diagram, s) {
+					t.Errorf("expected %q in:\n%s", s, diagram)
+				}
+			}
+		})
+	}
+}
+
+func TestDiagramHierarchy(t *testing.T) {
+	c, err := GetFSContract("./tests/hierarchy_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Status.CurrentState = []string{"Active.Suspended"}
+
+	expected := `stateDiagram-v2
+    state "Draft" as s0
+    state "Active" as s1
+    state s1 {
+        state "Active.Performing" as s2
+        state "Active.Suspended" as s3
+        s2 --> s3 : com.decombine.contract.suspend
+        s3 --> s2 : com.decombine.contract.resume
+    }
+    state "Terminated" as s4
+    [*] --> s0
+    s0 --> s2 : com.decombine.signature.sign
+    s1 --> s4 : com.decombine.contract.terminate
+    s4 --> [*]
+    classDef current fill:#fff3b0,stroke-width:2px
+    class s3 current
+`
+	if diagram := c.ToMermaid(); diagram != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, diagram)
+	}
+	// Transitions of a superstate leave its cluster.
+	if diagram := c.ToDOT(); !strings.Contains(diagram, `s1 -> s4 [label="com.decombine.contract.terminate", ltail=cluster_s1];`) {
+		t.Fatalf("expected the transition to leave the cluster of Active:\n%s", diagram)
+	}
+}
+
+func TestTransitionLabel(t *testing.T) {
+	tests := []struct {
+		transition Transition
+		expected   string
+	}{
+		{Transition{On: "com.decombine.signature.sign"}, "com.decombine.signature.sign"},
+		{Transition{On: "com.decombine.payment.overdue", After: "72h"}, "com.decombine.payment.overdue after 72h"},
+		{Transition{On: "com.decombine.contract.expire", At: "$expirationDate"}, "com.decombine.contract.expire at $expirationDate"},
+		{
+			Transition{On: "com.decombine.order.approve", Conditions: []Condition{{Name: "buyer"}, {Name: "small.order"}}},
+			"com.decombine.order.approve [buyer, small.order]",
+		},
+		{Transition{On: "com.decombine.payment.partial", Internal: true}, "com.decombine.payment.partial (internal)"},
+	}
+	for _, tt := range tests {
+		if label := transitionLabel(tt.transition); label != tt.expected {
+			t.Errorf("expected %q, got %q", tt.expected, label)
+		}
+	}
+}
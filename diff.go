@@ -0,0 +1,353 @@
+package slc
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+	ChangeRenamed = "renamed"
+)
+
+// ContractDiff is the structural difference between two Contract definitions. The Status and Signatures of the
+// Contracts are not compared.
+type ContractDiff struct {
+	// Changes of the Contract outside of its States. E.g., "version", "policy.directory", "regions.Payment"
+	Changes []Change `json:"changes,omitempty"`
+	// States added, removed or renamed. A State is renamed if it is removed and a State that only differs by
+	// its name is added.
+	States []Change `json:"states,omitempty"`
+	// Changes within the States present in both Contracts, by State name
+	StateChanges []StateDiff `json:"stateChanges,omitempty"`
+}
+
+// StateDiff is the difference between two definitions of a State.
+type StateDiff struct {
+	// The Name of the State in the second Contract
+	Name string `json:"name"`
+	// Changes of the State. E.g., "final", "entry", "variables.amount"
+	Changes []Change `json:"changes,omitempty"`
+	// Changes of the Transitions of the State. E.g., "Signing.to", "Signing.conditions.signed.path"
+	Transitions []Change `json:"transitions,omitempty"`
+}
+
+// Change is an addition, removal or modification of part of a Contract. Values are JSON, except strings.
+type Change struct {
+	// The Kind of Change, either "added", "removed", "changed" or "renamed"
+	Kind string `json:"kind"`
+	// The Path of the changed part. E.g., "Signing.conditions.signed.path"
+	Path string `json:"path"`
+	// The previous value
+	From string `json:"from,omitempty"`
+	// The new value
+	To string `json:"to,omitempty"`
+}
+
+// String describes the Change. E.g., "Signing.to changed from In Process to Active"
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeChanged:
+		return fmt.Sprintf("%s changed from %s to %s", c.Path, orNone(c.From), orNone(c.To))
+	case ChangeRenamed:
+		return fmt.Sprintf("%s renamed to %s", c.From, c.To)
+	}
+	return c.Path + " " + c.Kind
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+// Diff returns the structural difference between Contract definitions a and b.
+func Diff(a, b *Contract) *ContractDiff {
+	d := &ContractDiff{}
+	diffValue(&d.Changes, "name", a.Name, b.Name)
+	diffValue(&d.Changes, "version", a.Version, b.Version)
+	diffValue(&d.Changes, "text", a.Text, b.Text)
+	diffValue(&d.Changes, "source", a.Source, b.Source)
+	diffValue(&d.Changes, "policy.url", a.Policy.URL, b.Policy.URL)
+	diffValue(&d.Changes, "policy.branch", a.Policy.Branch, b.Policy.Branch)
+	diffValue(&d.Changes, "policy.directory", a.Policy.Directory, b.Policy.Directory)
+	diffValue(&d.Changes, "policy.bundle", a.Policy.Bundle, b.Policy.Bundle)
+	diffValue(&d.Changes, "policy.verification", a.Policy.Verification, b.Policy.Verification)
+	diffValue(&d.Changes, "policy.data", a.Policy.Data, b.Policy.Data)
+	diffValue(&d.Changes, "network", a.Network, b.Network)
+	diffValue(&d.Changes, "initial", a.State.Initial, b.State.Initial)
+	diffNamed(&d.Changes, "models", a.Models, b.Models, func(m ModelSource) string { return m.Name }, nil)
+	diffNamed(&d.Changes, "parties", a.Parties, b.Parties, func(p Party) string { return p.ID }, nil)
+	diffNamed(&d.Changes, "regions", a.State.Regions, b.State.Regions, func(r Region) string { return r.Name }, nil)
+
+	// States that only differ by name are renamed rather than removed and added.
+	statesA, statesB := statesByName(a), statesByName(b)
+	renamed := make(map[string]string)
+	var added, removed []string
+	for _, name := range slices.Sorted(maps.Keys(statesB)) {
+		if _, ok := statesA[name]; !ok {
+			added = append(added, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(statesA)) {
+		if _, ok := statesB[name]; ok {
+			continue
+		}
+		for i, to := range added {
+			if sameExceptName(statesA[name], statesB[to]) {
+				renamed[name] = to
+				added = append(added[:i], added[i+1:]...)
+				break
+			}
+		}
+		if _, ok := renamed[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	for _, name := range added {
+		d.States = append(d.States, Change{Kind: ChangeAdded, Path: name})
+	}
+	for _, name := range removed {
+		d.States = append(d.States, Change{Kind: ChangeRemoved, Path: name})
+	}
+	for _, from := range slices.Sorted(maps.Keys(renamed)) {
+		d.States = append(d.States, Change{Kind: ChangeRenamed, Path: from, From: from, To: renamed[from]})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(statesA)) {
+		sb, ok := statesB[name]
+		if !ok {
+			continue
+		}
+		if sd := diffState(statesA[name], sb, renamed); len(sd.Changes) > 0 || len(sd.Transitions) > 0 {
+			d.StateChanges = append(d.StateChanges, sd)
+		}
+	}
+	return d
+}
+
+// Empty determines if the Contract definitions are the same.
+func (d *ContractDiff) Empty() bool {
+	return len(d.Changes) == 0 && len(d.States) == 0 && len(d.StateChanges) == 0
+}
+
+// Summary describes each change, one per line. E.g., "state Review added", "state Draft: Signing.to changed from
+// In Process to Active"
+func (d *ContractDiff) Summary() []string {
+	var lines []string
+	for _, c := range d.Changes {
+		lines = append(lines, c.String())
+	}
+	for _, c := range d.States {
+		lines = append(lines, "state "+c.String())
+	}
+	for _, sd := range d.StateChanges {
+		for _, c := range append(sd.Changes, sd.Transitions...) {
+			lines = append(lines, fmt.Sprintf("state %s: %s", sd.Name, c))
+		}
+	}
+	return lines
+}
+
+// Markdown renders the difference as a Markdown document, e.g., for the review of a pull request.
+func (d *ContractDiff) Markdown() string {
+	var b strings.Builder
+	b.WriteString("## Contract changes\n")
+	if d.Empty() {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+	writeChanges := func(title string, changes []Change) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		for _, c := range changes {
+			b.WriteString("- " + markdownChange(c) + "\n")
+		}
+	}
+	writeChanges("Contract", d.Changes)
+	writeChanges("States", d.States)
+	for _, sd := range d.StateChanges {
+		writeChanges(fmt.Sprintf("State `%s`", sd.Name), sd.Changes)
+		writeChanges(fmt.Sprintf("Transitions of `%s`", sd.Name), sd.Transitions)
+	}
+	return b.String()
+}
+
+func markdownChange(c Change) string {
+	switch c.Kind {
+	case ChangeChanged:
+		return fmt.Sprintf("**changed** `%s`: `%s` → `%s`", c.Path, orNone(c.From), orNone(c.To))
+	case ChangeRenamed:
+		return fmt.Sprintf("**renamed** `%s` → `%s`", c.From, c.To)
+	}
+	return fmt.Sprintf("**%s** `%s`", c.Kind, c.Path)
+}
+
+// diffState returns the difference between two definitions of a State. Transitions to renamed States are
+// not reported as changed.
+func diffState(a, b State, renamed map[string]string) StateDiff {
+	sd := StateDiff{Name: b.Name}
+	diffValue(&sd.Changes, "parent", renamedState(a.Parent, renamed), b.Parent)
+	diffValue(&sd.Changes, "final", a.Final, b.Final)
+	diffValue(&sd.Changes, "entry", a.Entry, b.Entry)
+	diffValue(&sd.Changes, "exit", a.Exit, b.Exit)
+	diffNamed(&sd.Changes, "variables", a.Variables, b.Variables, func(v Variables) string { return v.Name }, diffVariable)
+
+	transitionsA := make(map[string]Transition)
+	for _, t := range a.Transitions {
+		transitionsA[t.Name] = t
+	}
+	transitionsB := make(map[string]Transition)
+	for _, t := range b.Transitions {
+		transitionsB[t.Name] = t
+	}
+	for _, name := range slices.Sorted(maps.Keys(transitionsB)) {
+		if _, ok := transitionsA[name]; !ok {
+			sd.Transitions = append(sd.Transitions, Change{Kind: ChangeAdded, Path: name})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(transitionsA)) {
+		tb, ok := transitionsB[name]
+		if !ok {
+			sd.Transitions = append(sd.Transitions, Change{Kind: ChangeRemoved, Path: name})
+			continue
+		}
+		ta := transitionsA[name]
+		diffValue(&sd.Transitions, name+".to", renamedState(ta.To, renamed), tb.To)
+		diffValue(&sd.Transitions, name+".on", ta.On, tb.On)
+		diffValue(&sd.Transitions, name+".after", ta.After, tb.After)
+		diffValue(&sd.Transitions, name+".at", ta.At, tb.At)
+		diffValue(&sd.Transitions, name+".join", ta.Join, tb.Join)
+		diffValue(&sd.Transitions, name+".internal", ta.Internal, tb.Internal)
+		diffValue(&sd.Transitions, name+".actions", ta.Actions, tb.Actions)
+		diffValue(&sd.Transitions, name+".class", ta.Class, tb.Class)
+		diffValue(&sd.Transitions, name+".schema", ta.Schema, tb.Schema)
+		diffConditions(&sd.Transitions, name+".conditions", ta.Conditions, tb.Conditions)
+	}
+	return sd
+}
+
+// diffConditions reports the Conditions added, removed and changed, by name. Changes to policy paths, inline
+// policies, engines and Roles are reported individually.
+func diffConditions(changes *[]Change, path string, a, b []Condition) {
+	conditionsA := make(map[string]Condition)
+	for _, c := range a {
+		conditionsA[c.Name] = c
+	}
+	conditionsB := make(map[string]Condition)
+	for _, c := range b {
+		conditionsB[c.Name] = c
+	}
+	for _, name := range slices.Sorted(maps.Keys(conditionsB)) {
+		if _, ok := conditionsA[name]; !ok {
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: path + "." + name})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(conditionsA)) {
+		cb, ok := conditionsB[name]
+		if !ok {
+			*changes = append(*changes, Change{Kind: ChangeRemoved, Path: path + "." + name})
+			continue
+		}
+		ca := conditionsA[name]
+		prefix := path + "." + name
+		diffValue(changes, prefix+".path", ca.Path, cb.Path)
+		diffValue(changes, prefix+".value", ca.Value, cb.Value)
+		diffValue(changes, prefix+".engine", ca.engine(), cb.engine())
+		diffValue(changes, prefix+".rego", ca.Rego, cb.Rego)
+		diffValue(changes, prefix+".roles", ca.Roles, cb.Roles)
+		diffValue(changes, prefix+".allOf", ca.AllOf, cb.AllOf)
+		diffValue(changes, prefix+".anyOf", ca.AnyOf, cb.AnyOf)
+		diffValue(changes, prefix+".not", ca.Not, cb.Not)
+	}
+}
+
+// diffVariable reports the changes of a Variable.
+func diffVariable(changes *[]Change, path string, a, b Variables) {
+	diffValue(changes, path+".type", a.Type, b.Type)
+	diffValue(changes, path+".default", a.Default, b.Default)
+	diffValue(changes, path+".ref", a.Ref, b.Ref)
+	diffValue(changes, path+".kind", a.Kind, b.Kind)
+}
+
+// diffValue reports a change of a value.
+func diffValue(changes *[]Change, path string, a, b interface{}) {
+	from, to := diffString(a), diffString(b)
+	if from != to {
+		*changes = append(*changes, Change{Kind: ChangeChanged, Path: path, From: from, To: to})
+	}
+}
+
+// diffNamed reports the elements of a list added, removed and changed, by name. Elements present in both lists
+// are compared with diff if it is set, or as a whole.
+func diffNamed[T any](changes *[]Change, path string, a, b []T, name func(T) string, diff func(*[]Change, string, T, T)) {
+	byName := func(items []T) map[string]T {
+		m := make(map[string]T)
+		for _, item := range items {
+			m[name(item)] = item
+		}
+		return m
+	}
+	ma, mb := byName(a), byName(b)
+	for _, n := range slices.Sorted(maps.Keys(mb)) {
+		if _, ok := ma[n]; !ok {
+			*changes = append(*changes, Change{Kind: ChangeAdded, Path: path + "." + n})
+		}
+	}
+	for _, n := range slices.Sorted(maps.Keys(ma)) {
+		vb, ok := mb[n]
+		if !ok {
+			*changes = append(*changes, Change{Kind: ChangeRemoved, Path: path + "." + n})
+			continue
+		}
+		if diff != nil {
+			diff(changes, path+"."+n, ma[n], vb)
+			continue
+		}
+		diffValue(changes, path+"."+n, ma[n], vb)
+	}
+}
+
+// diffString formats a value for comparison. Strings are kept as is, and other values are encoded as JSON.
+// Empty values are formatted as "".
+func diffString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+	switch s := string(b); s {
+	case "null", "{}", "[]", "false":
+		return ""
+	default:
+		return s
+	}
+}
+
+func statesByName(c *Contract) map[string]State {
+	states := make(map[string]State)
+	for _, s := range c.States() {
+		states[s.Name] = s
+	}
+	return states
+}
+
+// sameExceptName determines if two States only differ by name.
+func sameExceptName(a, b State) bool {
+	a.Name, b.Name = "", ""
+	return diffString(a) == diffString(b)
+}
+
+func renamedState(name string, renamed map[string]string) string {
+	if to, ok := renamed[name]; ok {
+		return to
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package slc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := Diff(a, a); !d.Empty() {
+		t.Fatalf("expected no changes, got %v", d.Summary())
+	}
+
+	b, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Version = "0.0.2"
+	b.State.States[0].Variables[0].Default = "100"
+	b.State.States[0].Variables = b.State.States[0].Variables[:1]
+	b.State.States[0].Transitions[0].To = "Accepted"
+	b.State.States[0].Transitions[0].On = "com.decombine.order.accept"
+	b.State.States[0].Transitions[0].Conditions[0].Value = "variables.amount > 5000.0"
+	b.State.States[1].Name = "Accepted"
+	b.State.States = append(b.State.States, State{Name: "Rejected", Final: true})
+
+	d := Diff(a, b)
+	expected := []string{
+		"version changed from 0.0.1 to 0.0.2",
+		"state Rejected added",
+		"state Approved renamed to Accepted",
+		"state Draft: variables.amount.default changed from 0 to 100",
+		"state Draft: variables.reviewerUniqueId removed",
+		"state Draft: Approval.on changed from com.decombine.order.approve to com.decombine.order.accept",
+		"state Draft: Approval.conditions.large.order.value changed from variables.amount > 1000.0 to variables.amount > 5000.0",
+	}
+	if !equalSlices(d.Summary(), expected) {
+		t.Fatalf("expected %v, got %v", expected, d.Summary())
+	}
+
+	markdown := strings.Join([]string{
+		"## Contract changes",
+		"",
+		"### Contract",
+		"",
+		"- **changed** `version`: `0.0.1` → `0.0.2`",
+		"",
+		"### States",
+		"",
+		"- **added** `Rejected`",
+		"- **renamed** `Approved` → `Accepted`",
+		"",
+		"### State `Draft`",
+		"",
+		"- **changed** `variables.amount.default`: `0` → `100`",
+		"- **removed** `variables.reviewerUniqueId`",
+		"",
+		"### Transitions of `Draft`",
+		"",
+		"- **changed** `Approval.on`: `com.decombine.order.approve` → `com.decombine.order.accept`",
+		"- **changed** `Approval.conditions.large.order.value`: `variables.amount > 1000.0` → `variables.amount > 5000.0`",
+		"",
+	}, "\n")
+	if d.Markdown() != markdown {
+		t.Fatalf("expected:\n%s\ngot:\n%s", markdown, d.Markdown())
+	}
+}
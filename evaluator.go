@@ -0,0 +1,190 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/open-policy-agent/opa/v1/rego"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+)
+
+const (
+	// EngineRego evaluates Conditions with Open Policy Agent (OPA) Rego. It is the default Engine.
+	EngineRego = "rego"
+	// EngineCEL evaluates Conditions with the Common Expression Language (CEL).
+	EngineCEL = "cel"
+	// EngineJSONLogic evaluates Conditions with JSONLogic rules.
+	EngineJSONLogic = "jsonlogic"
+)
+
+var (
+	ErrUnknownEngine      = errors.New("condition engine is not registered")
+	ErrReservedPolicyData = errors.New("policy data document is reserved")
+)
+
+// A ConditionEvaluator evaluates the policy of a Condition against the guard input of a TransitionCtx.
+// The policy is the content of the Condition policy file, or the inline policy of the Condition.
+type ConditionEvaluator interface {
+	Evaluate(ctx context.Context, condition Condition, policy []byte, input interface{}, variables []Variables) (bool, error)
+}
+
+// defaultEvaluators returns the ConditionEvaluators registered for each Engine by default.
+func defaultEvaluators(logger *slog.Logger) map[string]ConditionEvaluator {
+	return map[string]ConditionEvaluator{
+		EngineRego:      &RegoEvaluator{Logger: logger},
+		EngineCEL:       &CELEvaluator{},
+		EngineJSONLogic: &JSONLogicEvaluator{},
+	}
+}
+
+// engine returns the Engine of the Condition, defaulting to EngineRego.
+func (c Condition) engine() string {
+	if c.Engine == "" {
+		return EngineRego
+	}
+	return c.Engine
+}
+
+// RegoEvaluator evaluates Conditions with OPA Rego. The policy is a Rego module, and the Condition
+// Value is the query. The declared Default of State Variables replace their "$name" placeholders in the module,
+// and their current values are available as "data.variables". E.g., "data.variables.reviewerUniqueId". The
+// "variables" data document is therefore reserved.
+type RegoEvaluator struct {
+	Logger *slog.Logger
+	// Policies returns the policy directory whose modules are compiled along with the policy file of a
+	// Condition, so that policy files can import the packages of other modules, e.g., shared helpers. If nil,
+	// policy files are compiled alone. If the directory cannot be loaded, the Condition is not satisfied.
+	Policies func(ctx context.Context) (*PolicyDirectory, error)
+	// Data returns the data documents available to policies as "data", e.g., the data documents of an OPA bundle.
+	// If nil, policies are evaluated without data documents. If the documents cannot be loaded, the Condition is
+	// not satisfied.
+	Data func(ctx context.Context) (map[string]interface{}, error)
+}
+
+func (e *RegoEvaluator) Evaluate(ctx context.Context, condition Condition, policy []byte, input interface{}, variables []Variables) (bool, error) {
+	logger := e.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	var options []func(*rego.Rego)
+	if e.Policies != nil && condition.Path != "" {
+		d, err := e.Policies(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to load policy directory: %w", err)
+		}
+		options = d.RegoModules(policyPath(condition.Path))
+	}
+	// Current values of Variables may originate from event data, so they are passed as data rather than
+	// substituted into the module.
+	documents := make(map[string]interface{})
+	if e.Data != nil {
+		data, err := e.Data(ctx)
+		if err != nil {
+			return false, fmt.Errorf("failed to load policy data: %w", err)
+		}
+		if _, ok := data["variables"]; ok {
+			return false, fmt.Errorf("%w: variables", ErrReservedPolicyData)
+		}
+		maps.Copy(documents, data)
+	}
+	if len(variables) > 0 {
+		documents["variables"] = variableValues(variables)
+	}
+	if len(documents) > 0 {
+		options = append(options, rego.Store(inmem.NewFromObject(documents)))
+	}
+	query, err := NewRegoPolicy(ctx, condition.Name, condition.Value, policy, variables, logger, options...)
+	if err != nil {
+		return false, err
+	}
+	res, err := query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return false, err
+	}
+	return res.Allowed(), nil
+}
+
+// CELEvaluator evaluates Conditions with CEL. The policy is a CEL expression returning a bool, which can
+// reference the guard input as "input" and the State Variables as "variables". E.g., "input.amount > 1000"
+type CELEvaluator struct {
+	programs sync.Map
+}
+
+func (e *CELEvaluator) Evaluate(_ context.Context, _ Condition, policy []byte, input interface{}, variables []Variables) (bool, error) {
+	prg, err := e.program(string(policy))
+	if err != nil {
+		return false, err
+	}
+	out, _, err := prg.Eval(map[string]interface{}{
+		"input":     input,
+		"variables": variableValues(variables),
+	})
+	if err != nil {
+		return false, err
+	}
+	allowed, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("cel expression returned %s, not bool", out.Type().TypeName())
+	}
+	return allowed, nil
+}
+
+// program returns the compiled CEL program for an expression, compiling it once.
+func (e *CELEvaluator) program(expr string) (cel.Program, error) {
+	if prg, ok := e.programs.Load(expr); ok {
+		return prg.(cel.Program), nil
+	}
+	env, err := celEnv()
+	if err != nil {
+		return nil, err
+	}
+	ast, iss := env.Compile(expr)
+	if iss.Err() != nil {
+		return nil, iss.Err()
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	e.programs.Store(expr, prg)
+	return prg, nil
+}
+
+// celEnv returns the CEL environment declaring the guard input and State Variables.
+func celEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("input", cel.DynType),
+		cel.Variable("variables", cel.MapType(cel.StringType, cel.DynType)),
+		cel.CrossTypeNumericComparisons(true),
+	)
+}
+
+// variableValues returns the current values of State Variables by name, converted according to their Type.
+// Numbers are converted to float64, matching numbers decoded from JSON event data.
+func variableValues(variables []Variables) map[string]interface{} {
+	values := make(map[string]interface{}, len(variables))
+	for _, v := range variables {
+		value := v.current()
+		switch strings.ToLower(v.Type) {
+		case "int", "integer", "float", "number":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				values[v.Name] = n
+				continue
+			}
+		case "bool", "boolean":
+			if b, err := strconv.ParseBool(value); err == nil {
+				values[v.Name] = b
+				continue
+			}
+		}
+		values[v.Name] = value
+	}
+	return values
+}
@@ -0,0 +1,163 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCELEvaluator(t *testing.T) {
+	vars := []Variables{{Name: "threshold", Type: "number", Default: "1000"}}
+	tests := []struct {
+		name    string
+		expr    string
+		input   interface{}
+		allowed bool
+		err     bool
+	}{
+		{name: "Greater than", expr: "input.amount > 1000", input: map[string]interface{}{"amount": 1500.0}, allowed: true},
+		{name: "Not greater than", expr: "input.amount > 1000", input: map[string]interface{}{"amount": 500.0}, allowed: false},
+		{name: "Variable", expr: "input.amount > variables.threshold", input: map[string]interface{}{"amount": 1500.0}, allowed: true},
+		{name: "Party", expr: "input.party.role == 'buyer'", input: map[string]interface{}{"party": map[string]interface{}{"role": "buyer"}}, allowed: true},
+		{name: "Missing field", expr: "input.amount > 1000", input: map[string]interface{}{}, err: true},
+		{name: "Not bool", expr: "input.amount", input: map[string]interface{}{"amount": 1.0}, err: true},
+		{name: "Invalid expression", expr: "input.amount >", input: map[string]interface{}{}, err: true},
+	}
+
+	e := &CELEvaluator{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allowed, err := e.Evaluate(context.Background(), Condition{Engine: EngineCEL}, []byte(test.expr), test.input, vars)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if allowed != test.allowed {
+				t.Fatalf("expected %v, got %v", test.allowed, allowed)
+			}
+		})
+	}
+}
+
+func TestJSONLogicEvaluator(t *testing.T) {
+	vars := []Variables{{Name: "threshold", Type: "number", Default: "1000"}}
+	input := map[string]interface{}{
+		"amount":  1500,
+		"country": "DE",
+		"items":   []interface{}{map[string]interface{}{"qty": 2}, map[string]interface{}{"qty": 3}},
+	}
+	tests := []struct {
+		name    string
+		rule    string
+		allowed bool
+		err     bool
+	}{
+		{name: "Greater than", rule: `{">": [{"var": "input.amount"}, 1000]}`, allowed: true},
+		{name: "Variable", rule: `{"<": [{"var": "variables.threshold"}, {"var": "input.amount"}]}`, allowed: true},
+		{name: "Between", rule: `{"<=": [1000, {"var": "input.amount"}, 2000]}`, allowed: true},
+		{name: "In", rule: `{"in": [{"var": "input.country"}, ["FR", "NL"]]}`, allowed: false},
+		{name: "And", rule: `{"and": [{"==": [{"var": "input.country"}, "DE"]}, {"!": {"var": "input.missing"}}]}`, allowed: true},
+		{name: "Or", rule: `{"or": [false, {"===": [{"var": "input.amount"}, "1500"]}]}`, allowed: false},
+		{name: "Loose equality", rule: `{"==": [{"var": "input.amount"}, "1500"]}`, allowed: true},
+		{name: "If", rule: `{"if": [{">": [{"var": "input.amount"}, 2000]}, false, true]}`, allowed: true},
+		{name: "All", rule: `{"all": [{"var": "input.items"}, {">": [{"var": "qty"}, 1]}]}`, allowed: true},
+		{name: "Reduce", rule: `{"==": [{"reduce": [{"var": "input.items"}, {"+": [{"var": "current.qty"}, {"var": "accumulator"}]}, 0]}, 5]}`, allowed: true},
+		{name: "Missing", rule: `{"missing": ["input.amount", "input.currency"]}`, allowed: true},
+		{name: "Unknown operator", rule: `{"regex": ["a", "b"]}`, err: true},
+		{name: "Invalid JSON", rule: `{">": [`, err: true},
+	}
+
+	e := &JSONLogicEvaluator{}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			allowed, err := e.Evaluate(context.Background(), Condition{Engine: EngineJSONLogic}, []byte(test.rule), input, vars)
+			if (err != nil) != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if allowed != test.allowed {
+				t.Fatalf("expected %v, got %v", test.allowed, allowed)
+			}
+		})
+	}
+}
+
+type staticEvaluator bool
+
+func (e staticEvaluator) Evaluate(context.Context, Condition, []byte, interface{}, []Variables) (bool, error) {
+	return bool(e), nil
+}
+
+func TestWithConditionEvaluator(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/engines_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.State.States[0].Transitions[0].Conditions[1].Engine = "custom"
+
+	if _, err = NewStateMachine(ctx, "Draft", c); !errors.Is(err, ErrUnknownEngine) {
+		t.Fatalf("expected %v, got %v", ErrUnknownEngine, err)
+	}
+
+	sm, err := NewStateMachine(ctx, "Draft", c, WithConditionEvaluator("custom", staticEvaluator(true)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := TransitionCtx{Input: map[string]interface{}{"amount": 5000}}
+	if err = sm.FireCtx(NewTransitionContext(ctx, &input), "com.decombine.order.approve"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestRegoEvaluatorVariables(t *testing.T) {
+	ctx := context.Background()
+	// The current value of the Variable originates from event data, and must not alter the policy.
+	vars := []Variables{{Name: "reviewer", Type: "string", Default: "alice", Value: "x\"\nallow if true\n#"}}
+	e := &RegoEvaluator{}
+
+	placeholder := []byte("package review\n\nallow if input.user == \"$reviewer\"")
+	allowed, err := e.Evaluate(ctx, Condition{Name: "review", Value: "data.review.allow"}, placeholder, map[string]interface{}{"user": "bob"}, vars)
+	if err != nil || allowed {
+		t.Fatalf("expected denial, got %v, %v", allowed, err)
+	}
+	allowed, err = e.Evaluate(ctx, Condition{Name: "review", Value: "data.review.allow"}, placeholder, map[string]interface{}{"user": "alice"}, vars)
+	if err != nil || !allowed {
+		t.Fatalf("expected the declared default to be substituted, got %v, %v", allowed, err)
+	}
+
+	// Every placeholder is replaced, including those prefixed by the name of another Variable.
+	both := append(vars, Variables{Name: "reviewerRole", Type: "string", Default: "legal"})
+	placeholders := []byte("package review\n\nallow if {\n\tinput.user == \"$reviewer\"\n\tinput.role == \"$reviewerRole\"\n}")
+	allowed, err = e.Evaluate(ctx, Condition{Name: "review", Value: "data.review.allow"}, placeholders, map[string]interface{}{"user": "alice", "role": "legal"}, both)
+	if err != nil || !allowed {
+		t.Fatalf("expected every declared default to be substituted, got %v, %v", allowed, err)
+	}
+
+	current := []byte("package review\n\nallow if input.user == data.variables.reviewer")
+	allowed, err = e.Evaluate(ctx, Condition{Name: "review", Value: "data.review.allow"}, current, map[string]interface{}{"user": vars[0].Value}, vars)
+	if err != nil || !allowed {
+		t.Fatalf("expected the current value as data, got %v, %v", allowed, err)
+	}
+}
+
+func TestRegoEvaluatorData(t *testing.T) {
+	ctx := context.Background()
+	condition := Condition{Name: "small.order", Value: "data.order.allow"}
+	policy := []byte("package order\n\nallow if not data.limits.blocked")
+	input := map[string]interface{}{"amount": 500}
+
+	// Conditions are not satisfied without the data documents they depend on.
+	e := &RegoEvaluator{Data: func(context.Context) (map[string]interface{}, error) {
+		return nil, errors.New("unavailable")
+	}}
+	if allowed, err := e.Evaluate(ctx, condition, policy, input, nil); err == nil || allowed {
+		t.Fatalf("expected an error, got %v, %v", allowed, err)
+	}
+
+	// The variables data document is reserved for the State Variables.
+	e.Data = func(context.Context) (map[string]interface{}, error) {
+		return map[string]interface{}{"variables": map[string]interface{}{"reviewer": "alice"}}, nil
+	}
+	if allowed, err := e.Evaluate(ctx, condition, policy, input, nil); !errors.Is(err, ErrReservedPolicyData) || allowed {
+		t.Fatalf("expected %v, got %v, %v", ErrReservedPolicyData, allowed, err)
+	}
+}
@@ -19,6 +19,13 @@ const (
 	StrictHeaders      = false
 )
 
+const (
+	// TransitioningEventType is the type of the event published when the SLC transitions between States.
+	TransitioningEventType = "com.decombine.slc.transitioning"
+	// CompletedEventType is the type of the event published when the SLC enters a Final State.
+	CompletedEventType = "com.decombine.slc.completed"
+)
+
 // CreateEvent creates a new Event with the given type.
 func (c *Contract) CreateEvent(eventType, source string) (cloudevents.Event, error) {
 	if eventType == "" {
@@ -36,7 +43,7 @@ func (c *Contract) CreateEvent(eventType, source string) (cloudevents.Event, err
 // GetEvents returns a list of all events that the Contract StateConfiguration has registered.
 func (c *Contract) GetEvents() []string {
 	var evt []string
-	for _, s := range c.State.States {
+	for _, s := range c.States() {
 		for _, t := range s.Transitions {
 			// Register each event
 			evt = append(evt, t.On)
@@ -57,37 +64,225 @@ func (c *Contract) IsEventRegistered(event cloudevents.Event) bool {
 	return false
 }
 
-// ConsumeEvent consumes an Event and initiates State Transition if the Event is relevant.
+// ConsumeEvent consumes an Event and initiates State Transition if the Event is relevant. The Event is
+// dispatched to the StateConfiguration, using the eligible Transitions, and to each Region.
 func (r *Reconciler) ConsumeEvent(ctx context.Context, event *cloudevents.Event, eligible []Transition) error {
-	state, _ := r.getState(ctx)
-
-	for _, t := range eligible {
-		if t.On == "" {
-			// No event to process
-			continue
-		}
-		if t.On == event.Type() {
-			log.Printf("Event %s triggers transition to %s", event.Type(), t.To)
-			input := TransitionCtx{Input: string(event.Data())}
-			tCtx := NewTransitionContext(ctx, &input)
-			fire := r.FSM.FireCtx(tCtx, t.On, &input)
-			if fire != nil {
-				log.Printf("Transition failed with error: %v", fire.Error())
-			} else {
-				log.Printf("Transition successful")
-
-				if r.Client != nil {
-					err := r.checkForExitActions(ctx, state)
-					if err != nil {
-						log.Printf("Error checking for Exit Actions: %v", err)
-					}
+	transitions := map[string][]Transition{"": eligible}
+	for _, region := range r.regions()[1:] {
+		state, err := r.stateOf(ctx, region)
+		if err != nil {
+			return err
+		}
+		if transitions[region], err = r.Contract.GetTransitions(state.Name); err != nil {
+			return err
+		}
+	}
+	return r.consumeEvent(ctx, event, transitions, false)
+}
+
+// consumeEvent consumes an Event for the eligible Transitions of each Region, keyed by Region name, or
+// "" for the StateConfiguration. Events emitted by the Reconciler itself, such as timer events, are
+// trusted and are not authenticated.
+func (r *Reconciler) consumeEvent(ctx context.Context, event *cloudevents.Event, eligible map[string][]Transition, trusted bool) error {
+	select {
+	case <-r.done:
+		log.Printf("Event %s ignored: %v", event.ID(), ErrContractCompleted)
+		return nil
+	default:
+	}
+
+	// Find the Transitions triggered by the Event in each Region, in order of precedence. Timed Transitions
+	// are only triggered by their Timers, join Transitions once their Regions have joined, and Transitions
+	// declaring a Concerto class once the event data conforms to it.
+	timer := trusted && event.Source() == TimerEventSource
+	var regions []string
+	triggered := make(map[string][]Transition)
+	for _, region := range r.regions() {
+		for _, t := range eligible[region] {
+			if t.On == "" {
+				// No event to process
+				continue
+			}
+			if t.IsTimed() && !timer {
+				continue
+			}
+			if t.On == event.Type() && (len(t.Join) == 0 || r.joined(ctx, t)) {
+				if err := r.conforms(event, &t); err != nil {
+					log.Printf("Event %s rejected for transition %s: %v", event.ID(), t.Name, err)
+					continue
 				}
+				log.Printf("Event %s triggers transition to %s", event.Type(), t.To)
+				triggered[region] = append(triggered[region], t)
+			}
+		}
+		if len(triggered[region]) > 0 {
+			regions = append(regions, region)
+		}
+	}
+
+	if len(regions) == 0 {
+		return nil
+	}
+	data := eventInput(event)
+
+	// Only authenticated events act on behalf of a Party; the event attributes naming a Party are not.
+	var (
+		party  *Party
+		claims *EventClaims
+	)
+	attributed, err := r.Contract.PartyFromEvent(*event)
+	if err != nil {
+		log.Printf("Event %s rejected: %v", event.ID(), err)
+		return nil
+	}
+	if len(r.Authenticators) > 0 && !trusted {
+		claims, err = r.authenticate(ctx, *event)
+		if err != nil {
+			log.Printf("Event %s rejected: %v", event.ID(), err)
+			return nil
+		}
+		// The authenticated Party takes precedence over the unauthenticated event attributes.
+		if attributed != nil && (claims.Party == nil || claims.Party.ID != attributed.ID) {
+			log.Printf("Event %s rejected: %v", event.ID(), ErrPartyMismatch)
+			return nil
+		}
+		party = claims.Party
+	} else if r.unauthenticatedParties {
+		party = attributed
+	}
+
+	// Malformed event data never reaches the guard Conditions. Event data is validated once the event is
+	// authenticated, so that unauthenticated producers cannot publish rejected events: Concerto event data
+	// must conform to the class it declares, and the data of each triggered Transition to its Schema.
+	if err := r.conforms(event, nil); err != nil {
+		log.Printf("Event %s rejected: %v", event.ID(), err)
+		return nil
+	}
+	for _, region := range regions {
+		for _, t := range triggered[region] {
+			if err := r.validateSchema(ctx, event, t); err != nil {
+				log.Printf("Event %s rejected: %v", event.ID(), err)
+				return nil
+			}
+		}
+	}
+
+	input := TransitionCtx{Input: data, Party: party, Claims: claims, timer: timer}
+
+	// The triggered Transitions of a Region are fired in turn until one fires, so that the Transition firing
+	// is the one reconciled.
+	var fired bool
+	for _, region := range regions {
+		for _, t := range triggered[region] {
+			// Variables of the active States of the Region are resolved from the event data for its Transition.
+			input.variables = r.resolveVariables(ctx, region, data)
+			transitioned, completed, err := r.fire(ctx, region, t, &input)
+			if err != nil {
+				return err
+			}
+			if completed {
+				return nil
+			}
+			if transitioned {
+				fired = true
+				break
+			}
+		}
+	}
+
+	// Regions can only join once a Transition fired. A denied join Transition is not retried until then, and
+	// a join Transition does not join again, since the Regions remain joined after it fires.
+	if !fired || trusted && event.Source() == JoinEventSource {
+		return nil
+	}
+	return r.join(ctx)
+}
+
+// fire fires a Transition in the FSM of a Region, or of the StateConfiguration for "", and reconciles the
+// Exit actions of the States exited, the Transition Actions, and the Entry actions of the States entered.
+// It reports whether the Transition fired, and whether the SLC completed.
+func (r *Reconciler) fire(ctx context.Context, region string, t Transition, input *TransitionCtx) (bool, bool, error) {
+	sm, err := r.machine(region)
+	if err != nil {
+		return false, false, err
+	}
+	state, _ := r.stateOf(ctx, region)
+
+	// The PolicyDecisions of the guard Conditions are recorded per Transition, and only the Transition is
+	// permitted to fire.
+	input.Decisions = nil
+	input.source, input.transition = r.Contract.transitionSource(state.Name, t.Name), t.Name
+	tCtx := NewTransitionContext(ctx, input)
+	fire := sm.FireCtx(tCtx, t.On, input)
+	if fire != nil {
+		log.Printf("Transition failed with error: %v", fire.Error())
+		if r.observer != nil {
+			r.observer.observeTransition(region, t, state.Name, state.Name, false, nil, nil)
+		}
+		return false, false, nil
+	}
+	log.Printf("Transition successful")
+
+	r.updateVariables(input.variables)
+
+	current, err := r.stateOf(ctx, region)
+	if err != nil {
+		log.Printf("Error getting current state: %v", err)
+		return true, false, nil
+	}
+	// Internal Transitions neither exit nor enter a State.
+	var exited, entered []State
+	if !t.Internal {
+		exited, entered = r.Contract.TransitionPath(state.Name, current.Name)
+	}
+	if r.observer != nil {
+		r.observer.observeTransition(region, t, state.Name, current.Name, true, exited, entered)
+	}
+	r.Contract.Status.Audit = append(r.Contract.Status.Audit, newTransitionAuditEntry(region, t, state.Name, current.Name, input))
 
-				r.FSM.OnTransitioning()
+	if r.Client != nil {
+		for _, s := range exited {
+			if err = r.checkForExitActions(ctx, s); err != nil {
+				log.Printf("Error checking for Exit Actions: %v", err)
+			}
+		}
+		if err = r.reconcileAction(ctx, t.Actions); err != nil {
+			log.Printf("Error reconciling Transition Actions: %v", err)
+		}
+		for _, s := range entered {
+			if err = r.reconcileAction(ctx, s.Entry); err != nil {
+				log.Printf("Error reconciling Entry Actions: %v", err)
 			}
 		}
 	}
-	return nil
+
+	sm.OnTransitioning()
+
+	if err = r.rescheduleTimers(ctx, exited, entered); err != nil {
+		log.Printf("Error scheduling timed transitions: %v", err)
+	}
+
+	r.updateStatus(ctx)
+
+	// Only a Final State of the StateConfiguration completes the SLC; a Region entering a Final State
+	// has fulfilled its obligation.
+	if current.Final && region == "" {
+		if err = r.complete(ctx, current); err != nil {
+			log.Printf("Error completing contract: %v", err)
+		}
+		return true, true, nil
+	}
+	return true, false, nil
+}
+
+// eventInput returns the CloudEvent data as guard input. JSON data is decoded so that policies
+// can reference its fields; any other data is passed as a string.
+func eventInput(event *cloudevents.Event) interface{} {
+	var v interface{}
+	if err := json.Unmarshal(event.Data(), &v); err == nil {
+		return v
+	}
+	return string(event.Data())
 }
 
 func jetstreamToCloudEvent(m jetstream.Msg) (*cloudevents.Event, error) {
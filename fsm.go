@@ -3,10 +3,11 @@ package slc
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 
-	"github.com/open-policy-agent/opa/v1/rego"
 	"github.com/qmuntal/stateless"
 )
 
@@ -14,6 +15,57 @@ import (
 // Open Policy Agent (OPA) Rego policies.
 type TransitionCtx struct {
 	Input interface{} `json:"input" yaml:"input" toml:"input"`
+	// Party is the Party acting on the Transition, if known. The Party is injected into the
+	// guard input under the "party" key when Input is a JSON object.
+	Party *Party `json:"party,omitempty" yaml:"party,omitempty" toml:"party,omitempty"`
+	// Claims are the verified claims of the event producer, if the event was authenticated. The
+	// Claims are injected into the guard input under the "claims" key when Input is a JSON object.
+	Claims *EventClaims `json:"claims,omitempty" yaml:"claims,omitempty" toml:"claims,omitempty"`
+	// Decisions are the IDs of the PolicyDecisions logged by the guard Conditions evaluated for the Transition.
+	Decisions []string `json:"decisions,omitempty" yaml:"decisions,omitempty" toml:"decisions,omitempty"`
+
+	// variables are the values of State Variables resolved from the event data. They are visible to the guard
+	// Conditions, and saved to the Contract Status only once the Transition fires.
+	variables map[string]string
+	// source and transition select the Transition to fire among those triggered by the same event, by the State
+	// declaring it and its Name. The guards of the other Transitions deny. If empty, any Transition may fire.
+	source, transition string
+	// timer is set when a Timer of the Reconciler fires. Only then may timed Transitions fire.
+	timer bool
+}
+
+// selects determines if the Transition of a State may fire for the TransitionCtx.
+func (t *TransitionCtx) selects(state, transition string) bool {
+	return t.transition == "" || t.source == state && t.transition == transition
+}
+
+// guardInput returns the input used for evaluating guard Conditions.
+func (t TransitionCtx) guardInput() interface{} {
+	if t.Party == nil && t.Claims == nil {
+		return t.Input
+	}
+	in, ok := t.Input.(map[string]interface{})
+	if !ok {
+		return t.Input
+	}
+	out := make(map[string]interface{}, len(in)+2)
+	for k, v := range in {
+		out[k] = v
+	}
+	if t.Party != nil {
+		out["party"] = map[string]interface{}{
+			"id":   t.Party.ID,
+			"role": t.Party.Role,
+		}
+	}
+	if t.Claims != nil {
+		out["claims"] = map[string]interface{}{
+			"iss":    t.Claims.Issuer,
+			"sub":    t.Claims.Subject,
+			"claims": t.Claims.Claims,
+		}
+	}
+	return out
 }
 
 // key is an unexported type for keys defined in this package.
@@ -40,9 +92,19 @@ type FSMOption func(*FSMOptions)
 
 // FSMOptions is a struct that holds options for configuring the behavior of the FSM.
 type FSMOptions struct {
-	GitHubPAT      string
-	FilesystemPath string
-	Logger         *slog.Logger
+	GitHubPAT        string
+	FilesystemPath   string
+	Logger           *slog.Logger
+	VerifySignatures bool
+	VerifyAllParties bool
+	DigestOptions    []DigestOption
+	Evaluators       map[string]ConditionEvaluator
+	PolicyData       map[string]interface{}
+	DecisionLogger   DecisionLogger
+	DecisionInput    bool
+	RedactInput      []string
+
+	decisions func(GuardDecision)
 }
 
 // WithGitHubToken is an FSMOption that changes the default behavior of the FSM to use a GitHub Personal Access Token
@@ -69,10 +131,96 @@ func WithLogger(logger *slog.Logger) FSMOption {
 	}
 }
 
+// WithSignatureVerification is an FSMOption that refuses to construct the FSM for a Contract that is unsigned,
+// or whose Signatures do not match the current content digest. The digest covers the policy files of the
+// Conditions and the TextSources loaded by the FSM, which replace the same content of the DigestOption arguments,
+// and the policies evaluated are those loaded. See Contract.DigestContent.
+func WithSignatureVerification(opts ...DigestOption) FSMOption {
+	return func(o *FSMOptions) {
+		o.VerifySignatures = true
+		o.DigestOptions = opts
+	}
+}
+
+// WithAllPartySignatures is an FSMOption like WithSignatureVerification that also refuses to construct the FSM
+// for a Contract that has not been signed by every Party.
+func WithAllPartySignatures(opts ...DigestOption) FSMOption {
+	return func(o *FSMOptions) {
+		o.VerifySignatures = true
+		o.VerifyAllParties = true
+		o.DigestOptions = opts
+	}
+}
+
+// WithConditionEvaluator is an FSMOption that registers a ConditionEvaluator for Conditions declaring the given
+// Engine. Evaluators for the "rego", "cel" and "jsonlogic" Engines are registered by default and can be replaced.
+func WithConditionEvaluator(engine string, evaluator ConditionEvaluator) FSMOption {
+	return func(opts *FSMOptions) {
+		if opts.Evaluators == nil {
+			opts.Evaluators = make(map[string]ConditionEvaluator)
+		}
+		opts.Evaluators[engine] = evaluator
+	}
+}
+
+// WithPolicyData is an FSMOption that adds data documents available to Rego policies as "data", e.g.,
+// {"thresholds": {"max_amount": 5000}} as "data.thresholds.max_amount". The documents take precedence over the
+// data documents of the PolicySource.
+func WithPolicyData(data map[string]interface{}) FSMOption {
+	return func(opts *FSMOptions) {
+		if opts.PolicyData == nil {
+			opts.PolicyData = make(map[string]interface{})
+		}
+		for key, value := range data {
+			opts.PolicyData[key] = value
+		}
+	}
+}
+
+// WithDecisionLogger is an FSMOption that logs the evaluation of the policy of every guard Condition as a
+// PolicyDecision with the given DecisionLogger. The IDs of the PolicyDecisions are recorded in the TransitionCtx.
+func WithDecisionLogger(logger DecisionLogger) FSMOption {
+	return func(opts *FSMOptions) {
+		opts.DecisionLogger = logger
+	}
+}
+
+// WithDecisionInput is an FSMOption that includes the guard input in PolicyDecisions, in addition to its digest.
+// The values of the redacted dot-separated field paths are replaced, e.g., "claims" or "payment.card".
+func WithDecisionInput(redact ...string) FSMOption {
+	return func(opts *FSMOptions) {
+		opts.DecisionInput = true
+		opts.RedactInput = redact
+	}
+}
+
+// withGuardDecisions is an FSMOption that reports the decision of every guard Condition evaluated.
+func withGuardDecisions(f func(GuardDecision)) FSMOption {
+	return func(opts *FSMOptions) {
+		opts.decisions = f
+	}
+}
+
 // NewStateMachine initializes a Finite State Machine (FSM) for a given Smart Legal Contract. The FSM
 // is constructed based on the StateConfiguration of the Contract. The FSM is set to the current State
 // passed as an argument.
 func NewStateMachine(ctx context.Context, current string, c *Contract, opts ...FSMOption) (*stateless.StateMachine, error) {
+	return newStateMachine(ctx, current, c.State.Initial, c.State.States, c, opts...)
+}
+
+// NewRegionStateMachine initializes a Finite State Machine (FSM) for a Region of a given Smart Legal Contract.
+// The FSM is constructed based on the States of the Region, and is set to the current State passed as an argument.
+func NewRegionStateMachine(ctx context.Context, region, current string, c *Contract, opts ...FSMOption) (*stateless.StateMachine, error) {
+	r, err := c.GetRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	return newStateMachine(ctx, current, r.Initial, r.States, c, opts...)
+}
+
+// newStateMachine constructs the FSM for a set of States, being either the States of the StateConfiguration
+// or the States of a Region.
+func newStateMachine(ctx context.Context, current, initial string, states []State, c *Contract, opts ...FSMOption) (*stateless.StateMachine, error) {
 	options := &FSMOptions{}
 	for _, opt := range opts {
 		opt(options)
@@ -84,17 +232,61 @@ func NewStateMachine(ctx context.Context, current string, c *Contract, opts ...F
 		logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
 	}
 
+	evaluators := defaultEvaluators(logger)
+	guard := &conditionGuard{contract: c, options: options, evaluators: evaluators, logger: logger}
+
+	// Signatures are verified over the policies and text loaded, and the policies verified are pinned.
+	if options.VerifySignatures {
+		content, policies, err := guard.digestContent(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load digest content: %w", err)
+		}
+		digest := append(slices.Clone(options.DigestOptions), content...)
+		if options.VerifyAllParties {
+			err = c.VerifyAllParties(digest...)
+		} else {
+			err = c.Verify(digest...)
+		}
+		if err != nil {
+			return nil, err
+		}
+		guard.pinned = policies
+	}
+	// Policy files are compiled with the other modules of the policy directory, so that they can import them.
+	if options.FilesystemPath != "" || c.Policy.Directory != "" || c.Policy.Bundle != "" {
+		evaluators[EngineRego].(*RegoEvaluator).Policies = guard.policies
+	}
+	// The data documents of the policy directory, the data files of the PolicySource and the policy data of the
+	// options are available to every Rego policy.
+	if guard.directoryData() || len(c.Policy.Data) > 0 || len(options.PolicyData) > 0 {
+		evaluators[EngineRego].(*RegoEvaluator).Data = guard.data
+	}
+	for engine, evaluator := range options.Evaluators {
+		evaluators[engine] = evaluator
+	}
+	for _, s := range states {
+		for _, t := range s.Transitions {
+			for _, top := range t.Conditions {
+				for _, cond := range top.flatten() {
+					if _, ok := evaluators[cond.engine()]; cond.hasPolicy() && !ok {
+						return nil, fmt.Errorf("%w: %s (condition %s)", ErrUnknownEngine, cond.engine(), cond.Name)
+					}
+				}
+			}
+		}
+	}
+
 	var queue []string
 	var initialExists, currentExists bool = false, false
 	tree := stateless.NewStateMachine(current)
 
 	// Queue the states and validate the initial and current states exist.
-	for i := 0; i < len(c.State.States); i++ {
-		queue = append(queue, c.State.States[i].Name)
-		if c.State.States[i].Name == c.State.Initial {
+	for i := 0; i < len(states); i++ {
+		queue = append(queue, states[i].Name)
+		if states[i].Name == initial {
 			initialExists = true
 		}
-		if c.State.States[i].Name == current {
+		if states[i].Name == current {
 			currentExists = true
 		}
 	}
@@ -118,14 +310,21 @@ func NewStateMachine(ctx context.Context, current string, c *Contract, opts ...F
 			continue
 		}
 		visited[currentState] = true
-		states := c.State.States
 		for t := range states {
 
 			for i := 0; i < len(states[t].Transitions); i++ {
 				if states[t].Name != currentState {
 					continue
 				}
-				var guards []stateless.GuardFunc
+				// Only the Transition selected by the TransitionCtx fires, whichever other Transitions share its event,
+				// and timed Transitions only fire when their Timer is due, whoever else emits their event.
+				guards := []stateless.GuardFunc{func(ctx context.Context, _ ...any) bool {
+					inner, ok := FromContext(ctx)
+					if states[t].Transitions[i].IsTimed() && (!ok || !inner.timer) {
+						return false
+					}
+					return !ok || inner.selects(states[t].Name, states[t].Transitions[i].Name)
+				}}
 				for j := 0; j < len(states[t].Transitions[i].Conditions); j++ {
 
 					// If there are no conditions, the transition is always valid.
@@ -135,40 +334,47 @@ func NewStateMachine(ctx context.Context, current string, c *Contract, opts ...F
 						if !ok {
 							inner = &TransitionCtx{Input: ""}
 						}
-						var policyContent []byte
-
-						// Retrieve any policy files that will be required for the FSM to evaluate transitions.
-						// For now, these are loaded into memory. This is also being done in a loop, which is not ideal,
-						// but it simplifies the scenario for now. This should be refactored to load the policy files
-						// earlier in the function, but that will then require a mapping between policy files and conditions.
-						// TODO: This may have a large memory footprint; profiling should be done in various scenarios when feasible.
-						// TODO: Refactor this to a cleaner implementation.
-						if options.FilesystemPath != "" {
-
-							// Ensure the path ends with a trailing slash to avoid policy files not being found.
-							if options.FilesystemPath[len(options.FilesystemPath)-1:] != "/" {
-								options.FilesystemPath += "/"
-							}
-
-							policyContent, _ = os.ReadFile(options.FilesystemPath + states[t].Transitions[i].Conditions[j].Path)
-						} else if options.GitHubPAT != "" {
-							policyContent, _ = getPolicyFile(ctx, c.Policy.URL, c.Policy.Branch, options.GitHubPAT, states[t].Transitions[i].Conditions[j].Path)
-						} else {
-							policyContent, _ = getPolicyFile(ctx, c.Policy.URL, c.Policy.Branch, "", states[t].Transitions[i].Conditions[j].Path)
+						if !inner.selects(states[t].Name, states[t].Transitions[i].Name) {
+							return false
 						}
+
 						var vars []Variables
 						if states[t].Variables != nil {
-							retrieved, _ := c.GetVariables(states[t].Name)
+							retrieved, _ := c.ResolvedVariables(states[t].Name)
+							for k := range retrieved {
+								if value, ok := inner.variables[retrieved[k].Name]; ok {
+									retrieved[k].Value = value
+								}
+							}
 							vars = append(vars, retrieved...)
 						}
 
-						logger.Debug("Policy file content", "path", states[t].Transitions[i].Conditions[j].Path, "content", string(policyContent))
-
-						return regoCondition(*inner, states[t].Transitions[i].Conditions[j], policyContent, vars, logger)
+						condition := states[t].Transitions[i].Conditions[j]
+						allowed := guard.evaluate(ctx, states[t].Name, states[t].Transitions[i].Name, condition, inner, vars)
+						if options.decisions != nil {
+							options.decisions(GuardDecision{
+								State:      states[t].Name,
+								Transition: states[t].Transitions[i].Name,
+								Condition:  condition.Name,
+								Allowed:    allowed,
+							})
+						}
+						return allowed
 					})
 				}
 
-				tree.Configure(currentState).Permit(states[t].Transitions[i].On, states[t].Transitions[i].To, guards...)
+				// Internal Transitions remain in the State without exiting it, and Transitions to the source State
+				// exit and re-enter it.
+				switch transition := states[t].Transitions[i]; {
+				case transition.Internal:
+					tree.Configure(currentState).InternalTransition(transition.On, func(context.Context, ...any) error {
+						return nil
+					}, guards...)
+				case transition.To == currentState:
+					tree.Configure(currentState).PermitReentry(transition.On, guards...)
+				default:
+					tree.Configure(currentState).Permit(transition.On, transition.To, guards...)
+				}
 				if !visited[states[t].Name] {
 					queue = append(queue, states[t].Name)
 				}
@@ -177,21 +383,15 @@ func NewStateMachine(ctx context.Context, current string, c *Contract, opts ...F
 		}
 	}
 
-	return tree, nil
-}
-
-func regoCondition(tCtx TransitionCtx, condition Condition, policyContent []byte, variables []Variables, logger *slog.Logger) bool {
-	ctx := context.Background()
-
-	policy, err := NewRegoPolicy(ctx, condition.Name, condition.Value, policyContent, variables, logger)
-	if err != nil {
-		panic(err)
-	}
-	res, err := policy.Eval(ctx, rego.EvalInput(tCtx.Input))
-	if err != nil {
-		panic(err)
+	// Nest substates within their superstates so that Transitions are inherited, and entry and exit
+	// follow the State hierarchy.
+	for _, s := range states {
+		if s.Parent != "" {
+			tree.Configure(s.Name).SubstateOf(s.Parent)
+		}
 	}
-	return res.Allowed()
+
+	return tree, nil
 }
 
 // StateTransitionValidator evaluates a State Machine and a possible transition
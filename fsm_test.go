@@ -18,6 +18,7 @@ type fsmtest struct {
 type fsmEvent struct {
 	name      string
 	payload   any
+	party     *Party
 	shouldErr bool
 }
 
@@ -104,6 +105,115 @@ func TestStateMachineTransitions(t *testing.T) {
 			},
 			shouldErr: false,
 		},
+		{
+			name:     "Test NewStateMachine with party roles",
+			contract: "./tests/parties_ok.yaml",
+			state:    "Draft",
+			options: []FSMOption{
+				WithFSPolicyFiles("./tests/policies/"),
+			},
+			events: []fsmEvent{
+				{
+					name:      "com.decombine.signature.sign",
+					payload:   map[string]interface{}{"accepted": true},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.signature.sign",
+					payload:   map[string]interface{}{"accepted": true},
+					party:     &Party{ID: "acme", Role: "seller"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.signature.sign",
+					payload:   map[string]interface{}{"accepted": false},
+					party:     &Party{ID: "globex", Role: "buyer"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.signature.sign",
+					payload:   map[string]interface{}{"accepted": true},
+					party:     &Party{ID: "globex", Role: "buyer"},
+					shouldErr: false,
+				},
+				{
+					name:      "com.decombine.contract.fulfilled",
+					payload:   map[string]interface{}{},
+					party:     &Party{ID: "globex", Role: "buyer"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.contract.fulfilled",
+					payload:   map[string]interface{}{},
+					party:     &Party{ID: "acme", Role: "seller"},
+					shouldErr: false,
+				},
+			},
+			shouldErr: false,
+		},
+		{
+			name:     "Test NewStateMachine with condition combinators",
+			contract: "./tests/conditions_ok.yaml",
+			state:    "Draft",
+			events: []fsmEvent{
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 5000},
+					party:     &Party{ID: "acme", Role: "seller"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 500, "flagged": true},
+					party:     &Party{ID: "acme", Role: "seller"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 500, "flagged": false},
+					party:     &Party{ID: "acme", Role: "seller"},
+					shouldErr: false,
+				},
+			},
+			shouldErr: false,
+		},
+		{
+			name:     "Test NewStateMachine with condition engines",
+			contract: "./tests/engines_ok.yaml",
+			state:    "Draft",
+			events: []fsmEvent{
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 500, "country": "DE"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 5000, "country": "US"},
+					shouldErr: true,
+				},
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 5000, "country": "DE"},
+					shouldErr: false,
+				},
+			},
+			shouldErr: false,
+		},
+		{
+			name:     "Test NewStateMachine with condition combinators for any of roles",
+			contract: "./tests/conditions_ok.yaml",
+			state:    "Draft",
+			events: []fsmEvent{
+				{
+					name:      "com.decombine.order.approve",
+					payload:   map[string]interface{}{"amount": 5000, "flagged": true},
+					party:     &Party{ID: "globex", Role: "buyer"},
+					shouldErr: false,
+				},
+			},
+			shouldErr: false,
+		},
 	}
 
 	t.Parallel()
@@ -124,7 +234,7 @@ func TestStateMachineTransitions(t *testing.T) {
 			}
 
 			for _, event := range test.events {
-				input := TransitionCtx{Input: event.payload}
+				input := TransitionCtx{Input: event.payload, Party: event.party}
 				ctx = NewTransitionContext(ctx, &input)
 				err = sm.FireCtx(ctx, event.name)
 				if err != nil && !event.shouldErr {
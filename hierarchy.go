@@ -0,0 +1,125 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrParentNotFound = errors.New("parent state not found")
+	ErrHierarchyCycle = errors.New("state hierarchy contains a cycle")
+)
+
+// Ancestors returns the names of the superstates of a State, starting with its Parent. The walk
+// stops at an undeclared Parent or a cycle; see ValidateHierarchy.
+func (c *Contract) Ancestors(state string) []string {
+	var ancestors []string
+	s, err := c.GetState(state)
+	for err == nil && s.Parent != "" && s.Parent != state && !slices.Contains(ancestors, s.Parent) {
+		ancestors = append(ancestors, s.Parent)
+		s, err = c.GetState(s.Parent)
+	}
+	return ancestors
+}
+
+// IsSubstateOf determines if a State is the same as, or nested within, the given superstate.
+func (c *Contract) IsSubstateOf(state, superstate string) bool {
+	return state == superstate || slices.Contains(c.Ancestors(state), superstate)
+}
+
+// GetTransitions returns the Transitions available in a State, including the Transitions inherited
+// from its superstates. Transitions of the State come first, followed by those of each ancestor.
+func (c *Contract) GetTransitions(state string) ([]Transition, error) {
+	s, err := c.GetState(state)
+	if err != nil {
+		return nil, err
+	}
+	transitions := slices.Clone(s.Transitions)
+	for _, a := range c.Ancestors(state) {
+		p, err := c.GetState(a)
+		if err != nil {
+			return nil, err
+		}
+		transitions = append(transitions, p.Transitions...)
+	}
+	return transitions, nil
+}
+
+// transitionSource returns the State declaring a Transition of a State by Name: the State itself, or the
+// nearest of its superstates.
+func (c *Contract) transitionSource(state, transition string) string {
+	for _, name := range append([]string{state}, c.Ancestors(state)...) {
+		s, err := c.GetState(name)
+		if err != nil {
+			continue
+		}
+		for _, t := range s.Transitions {
+			if t.Name == transition {
+				return name
+			}
+		}
+	}
+	return state
+}
+
+// TransitionPath returns the States exited and entered when transitioning from the source State to
+// the destination State. Exited States are ordered from the source outwards, and entered States from
+// the outermost superstate inwards to the destination, per UML state machine semantics. States shared
+// by the source and destination hierarchy are neither exited nor entered, except for a transition from
+// a State to itself, which exits and re-enters it.
+func (c *Contract) TransitionPath(source, destination string) (exited, entered []State) {
+	from := append([]string{source}, c.Ancestors(source)...)
+	to := append([]string{destination}, c.Ancestors(destination)...)
+
+	for _, name := range from {
+		if slices.Contains(to, name) && source != destination {
+			break
+		}
+		if s, err := c.GetState(name); err == nil {
+			exited = append(exited, s)
+		}
+		if source == destination {
+			break
+		}
+	}
+
+	for i := len(to) - 1; i >= 0; i-- {
+		if slices.Contains(from, to[i]) && source != destination {
+			continue
+		}
+		if source == destination && to[i] != destination {
+			continue
+		}
+		if s, err := c.GetState(to[i]); err == nil {
+			entered = append(entered, s)
+		}
+	}
+	return exited, entered
+}
+
+// ValidateHierarchy validates that every State Parent is declared and that the State hierarchy is acyclic.
+func (c *Contract) ValidateHierarchy() error {
+	for _, s := range c.States() {
+		seen := []string{s.Name}
+		current := s
+		for current.Parent != "" {
+			if slices.Contains(seen, current.Parent) {
+				return fmt.Errorf("%w: %s", ErrHierarchyCycle, s.Name)
+			}
+			parent, err := c.GetState(current.Parent)
+			if err != nil {
+				return fmt.Errorf("%w: %s (state %s)", ErrParentNotFound, current.Parent, current.Name)
+			}
+			seen = append(seen, parent.Name)
+			current = parent
+		}
+	}
+	return nil
+}
+
+// IsInState determines if the SLC is currently in the given State, or in a substate of it.
+func (r *Reconciler) IsInState(ctx context.Context, state string) (bool, error) {
+	return r.FSM.IsInStateCtx(ctx, state)
+}
@@ -0,0 +1,155 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func stateNames(states []State) []string {
+	var names []string
+	for _, s := range states {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestHierarchicalStateMachine(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/hierarchy_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, trigger := range []string{
+		"com.decombine.signature.sign",
+		"com.decombine.contract.suspend",
+	} {
+		if err = sm.FireCtx(ctx, trigger); err != nil {
+			t.Fatalf("unexpected error firing %s: %s", trigger, err)
+		}
+	}
+
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{})
+	for _, state := range []string{"Active", "Active.Suspended"} {
+		in, err := r.IsInState(ctx, state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !in {
+			t.Fatalf("expected to be in state %s", state)
+		}
+	}
+
+	// Terminate is inherited from the Active superstate.
+	eligible, err := r.eligibleTransitions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eligible) != 2 || eligible[1].Name != "Terminate" {
+		t.Fatalf("expected inherited Terminate transition, got %v", eligible)
+	}
+	if err = sm.FireCtx(ctx, "com.decombine.contract.terminate"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if state, _ := sm.State(ctx); state != "Terminated" {
+		t.Fatalf("expected state Terminated, got %v", state)
+	}
+}
+
+func TestTransitionPath(t *testing.T) {
+	c, err := GetFSContract("./tests/hierarchy_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		source      string
+		destination string
+		exited      []string
+		entered     []string
+	}{
+		{
+			name:        "Into substate",
+			source:      "Draft",
+			destination: "Active.Performing",
+			exited:      []string{"Draft"},
+			entered:     []string{"Active", "Active.Performing"},
+		},
+		{
+			name:        "Between siblings",
+			source:      "Active.Performing",
+			destination: "Active.Suspended",
+			exited:      []string{"Active.Performing"},
+			entered:     []string{"Active.Suspended"},
+		},
+		{
+			name:        "Out of superstate",
+			source:      "Active.Suspended",
+			destination: "Terminated",
+			exited:      []string{"Active.Suspended", "Active"},
+			entered:     []string{"Terminated"},
+		},
+		{
+			name:        "Self transition",
+			source:      "Active.Suspended",
+			destination: "Active.Suspended",
+			exited:      []string{"Active.Suspended"},
+			entered:     []string{"Active.Suspended"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exited, entered := c.TransitionPath(test.source, test.destination)
+			if !equalSlices(stateNames(exited), test.exited) {
+				t.Errorf("expected exited %v, got %v", test.exited, stateNames(exited))
+			}
+			if !equalSlices(stateNames(entered), test.entered) {
+				t.Errorf("expected entered %v, got %v", test.entered, stateNames(entered))
+			}
+		})
+	}
+}
+
+func TestValidateHierarchy(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []State
+		err    error
+	}{
+		{
+			name:   "Valid",
+			states: []State{{Name: "Active"}, {Name: "Active.Performing", Parent: "Active"}},
+		},
+		{
+			name:   "Parent not found",
+			states: []State{{Name: "Active.Performing", Parent: "Active"}},
+			err:    ErrParentNotFound,
+		},
+		{
+			name:   "Cycle",
+			states: []State{{Name: "A", Parent: "B"}, {Name: "B", Parent: "A"}},
+			err:    ErrHierarchyCycle,
+		},
+		{
+			name:   "Self parent",
+			states: []State{{Name: "A", Parent: "A"}},
+			err:    ErrHierarchyCycle,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Contract{State: StateConfiguration{States: test.states}}
+			if err := c.ValidateHierarchy(); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/diegoholiveira/jsonlogic/v3"
+)
+
+var (
+	ErrInvalidJSONLogic = errors.New("jsonlogic rule is invalid")
+)
+
+// JSONLogicEvaluator evaluates Conditions with JSONLogic (https://jsonlogic.com). The policy is a JSON rule
+// whose data holds the guard input as "input" and the State Variables as "variables".
+// E.g., {">": [{"var": "input.amount"}, 1000]}
+type JSONLogicEvaluator struct{}
+
+func (e *JSONLogicEvaluator) Evaluate(_ context.Context, _ Condition, policy []byte, input interface{}, variables []Variables) (bool, error) {
+	rule, err := parseJSONLogic(policy)
+	if err != nil {
+		return false, err
+	}
+	// Round trip the input through JSON so that it holds the same types as the rule.
+	var in interface{}
+	if b, err := json.Marshal(input); err == nil {
+		_ = json.Unmarshal(b, &in)
+	}
+	data := map[string]interface{}{
+		"input":     in,
+		"variables": variableValues(variables),
+	}
+	// The rule is applied with the JSONLogic rules of truthiness.
+	out, err := jsonlogic.ApplyInterface(map[string]interface{}{"!!": []interface{}{rule}}, data)
+	if err != nil {
+		return false, err
+	}
+	allowed, _ := out.(bool)
+	return allowed, nil
+}
+
+// parseJSONLogic decodes a JSONLogic rule, validating that it only applies supported operators.
+func parseJSONLogic(policy []byte) (interface{}, error) {
+	var rule interface{}
+	if err := json.Unmarshal(policy, &rule); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidJSONLogic, err)
+	}
+	if !jsonlogic.ValidateJsonLogic(rule) {
+		return nil, ErrInvalidJSONLogic
+	}
+	return rule, nil
+}
@@ -0,0 +1,186 @@
+package slc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// astModel is a model of the Concerto metamodel (concerto.metamodel@1.0.0).
+type astModel struct {
+	Class        string           `json:"$class"`
+	Namespace    string           `json:"namespace"`
+	Imports      []astImport      `json:"imports"`
+	Declarations []astDeclaration `json:"declarations"`
+	Models       []astModel       `json:"models"`
+}
+
+type astImport struct {
+	Class     string   `json:"$class"`
+	Namespace string   `json:"namespace"`
+	Name      string   `json:"name"`
+	Types     []string `json:"types"`
+}
+
+type astTypeIdentifier struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+func (t *astTypeIdentifier) String() string {
+	if t.Namespace == "" {
+		return t.Name
+	}
+	return t.Namespace + "." + t.Name
+}
+
+type astDeclaration struct {
+	Class           string             `json:"$class"`
+	Name            string             `json:"name"`
+	IsAbstract      bool               `json:"isAbstract"`
+	SuperType       *astTypeIdentifier `json:"superType"`
+	Properties      []astProperty      `json:"properties"`
+	Validator       *astValidator      `json:"validator"`
+	LengthValidator *astValidator      `json:"lengthValidator"`
+}
+
+type astProperty struct {
+	Class           string             `json:"$class"`
+	Name            string             `json:"name"`
+	IsArray         bool               `json:"isArray"`
+	IsOptional      bool               `json:"isOptional"`
+	Type            *astTypeIdentifier `json:"type"`
+	Validator       *astValidator      `json:"validator"`
+	LengthValidator *astValidator      `json:"lengthValidator"`
+}
+
+type astValidator struct {
+	Pattern   string   `json:"pattern"`
+	Flags     string   `json:"flags"`
+	Lower     *float64 `json:"lower"`
+	Upper     *float64 `json:"upper"`
+	MinLength *int     `json:"minLength"`
+	MaxLength *int     `json:"maxLength"`
+}
+
+// astKind returns the unqualified name of a metamodel "$class". E.g., "concerto.metamodel@1.0.0.StringProperty"
+// yields "StringProperty".
+func astKind(class string) string {
+	return class[strings.LastIndex(class, ".")+1:]
+}
+
+// parseConcertoAST parses a Concerto model, or a set of models, declared as a JSON AST of the Concerto metamodel.
+// See https://concerto.accordproject.org/docs/design/specification/model-metamodel
+func parseConcertoAST(src []byte) ([]*concertoDeclaration, error) {
+	var model astModel
+	if err := json.Unmarshal(src, &model); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModel, err)
+	}
+	models := model.Models
+	if astKind(model.Class) != "Models" {
+		models = []astModel{model}
+	}
+
+	var declarations []*concertoDeclaration
+	for _, m := range models {
+		if m.Namespace == "" {
+			return nil, fmt.Errorf("%w: model has no namespace", ErrInvalidModel)
+		}
+		imports := make(map[string]string)
+		for _, i := range m.Imports {
+			for _, typ := range i.Types {
+				imports[typ] = i.Namespace + "." + typ
+			}
+			if i.Name != "" {
+				imports[i.Name] = i.Namespace + "." + i.Name
+			}
+		}
+		for _, ad := range m.Declarations {
+			d, err := ad.declaration(m.Namespace, imports)
+			if err != nil {
+				return nil, err
+			}
+			if d != nil {
+				declarations = append(declarations, d)
+			}
+		}
+	}
+	return declarations, nil
+}
+
+// declaration converts a metamodel declaration. Map declarations are not supported, and are skipped.
+func (ad astDeclaration) declaration(namespace string, imports map[string]string) (*concertoDeclaration, error) {
+	d := &concertoDeclaration{name: ad.Name, namespace: namespace, abstract: ad.IsAbstract, imports: imports}
+	if ad.SuperType != nil {
+		d.superType = ad.SuperType.String()
+	}
+	kind := astKind(ad.Class)
+	switch kind {
+	case "ConceptDeclaration", "AssetDeclaration", "ParticipantDeclaration", "TransactionDeclaration", "EventDeclaration":
+		d.kind = strings.ToLower(strings.TrimSuffix(kind, "Declaration"))
+	case "EnumDeclaration":
+		d.kind = "enum"
+		for _, prop := range ad.Properties {
+			d.values = append(d.values, prop.Name)
+		}
+		return d, nil
+	case "StringScalar", "IntegerScalar", "LongScalar", "DoubleScalar", "BooleanScalar", "DateTimeScalar":
+		d.kind = "scalar"
+		scalar, err := astProperty{
+			Class:           strings.TrimSuffix(kind, "Scalar") + "Property",
+			Name:            ad.Name,
+			Validator:       ad.Validator,
+			LengthValidator: ad.LengthValidator,
+		}.property()
+		d.scalar = &scalar
+		return d, err
+	case "MapDeclaration":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("%w: unsupported declaration %s", ErrInvalidModel, ad.Class)
+	}
+	for _, ap := range ad.Properties {
+		prop, err := ap.property()
+		if err != nil {
+			return nil, fmt.Errorf("%w (declaration %s)", err, ad.Name)
+		}
+		d.properties = append(d.properties, prop)
+	}
+	return d, nil
+}
+
+// property converts a metamodel property.
+func (ap astProperty) property() (concertoProperty, error) {
+	prop := concertoProperty{name: ap.Name, array: ap.IsArray, optional: ap.IsOptional}
+	switch kind := astKind(ap.Class); kind {
+	case "StringProperty", "IntegerProperty", "LongProperty", "DoubleProperty", "BooleanProperty", "DateTimeProperty":
+		prop.typ = strings.TrimSuffix(kind, "Property")
+	case "ObjectProperty", "RelationshipProperty":
+		if ap.Type == nil {
+			return prop, fmt.Errorf("%w: property %s has no type", ErrInvalidModel, ap.Name)
+		}
+		prop.typ = ap.Type.String()
+		prop.relationship = kind == "RelationshipProperty"
+	default:
+		return prop, fmt.Errorf("%w: unsupported property %s", ErrInvalidModel, ap.Class)
+	}
+	if v := ap.Validator; v != nil {
+		if v.Pattern != "" {
+			pattern := v.Pattern
+			if strings.Contains(v.Flags, "i") {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return prop, fmt.Errorf("%w: property %s: %v", ErrInvalidModel, ap.Name, err)
+			}
+			prop.regex = re
+		}
+		prop.lower, prop.upper = v.Lower, v.Upper
+	}
+	if v := ap.LengthValidator; v != nil {
+		prop.minLength, prop.maxLength = v.MinLength, v.MaxLength
+	}
+	return prop, nil
+}
@@ -0,0 +1,140 @@
+package slc
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+const (
+	// PartyExtension is the CloudEvent extension attribute naming the ID of the acting Party.
+	PartyExtension = "partyid"
+)
+
+var (
+	ErrPartyNotFound    = errors.New("party not found")
+	ErrDuplicateParty   = errors.New("party is declared more than once")
+	ErrRoleNotDeclared  = errors.New("role is not declared by any party")
+	ErrPartyKeyMismatch = errors.New("signature public key does not match the declared party key")
+)
+
+// A Party is a participant in a Smart Legal Contract acting in a Role.
+type Party struct {
+	// ID is the unique identifier of the Party within the SLC. E.g., "buyer"
+	ID string `json:"id" yaml:"id" toml:"id" validate:"required"`
+	// Name is the friendly Name of the Party. E.g., "Acme Corporation"
+	Name string `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	// Role of the Party referenced by Transition Conditions. E.g., "admin", "reviewer"
+	Role string `json:"role" yaml:"role" toml:"role" validate:"required"`
+	// Subject is the OIDC subject (sub claim) identifying the Party.
+	Subject string `json:"subject,omitempty" yaml:"subject,omitempty" toml:"subject,omitempty"`
+	// Issuer is the OIDC issuer (iss claim) of the Party Subject. Defaults to the Network Issuer.
+	Issuer string `json:"issuer,omitempty" yaml:"issuer,omitempty" toml:"issuer,omitempty"`
+	// PublicKey is the PEM encoded public key of the Party used to verify Signatures.
+	PublicKey string `json:"publicKey,omitempty" yaml:"publicKey,omitempty" toml:"publicKey,omitempty"`
+}
+
+// HasRole determines if the Party acts in any of the given roles.
+func (p *Party) HasRole(roles ...string) bool {
+	if p == nil {
+		return false
+	}
+	return slices.Contains(roles, p.Role)
+}
+
+// GetParty returns the Party with the given ID.
+func (c *Contract) GetParty(id string) (Party, error) {
+	for _, p := range c.Parties {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return Party{}, ErrPartyNotFound
+}
+
+// GetPartyBySubject returns the Party identified by an OIDC issuer and subject. A Party without
+// an Issuer is matched against the Network Issuer.
+func (c *Contract) GetPartyBySubject(issuer, subject string) (Party, error) {
+	for _, p := range c.Parties {
+		if p.Subject == "" || p.Subject != subject {
+			continue
+		}
+		iss := p.Issuer
+		if iss == "" {
+			iss = c.Network.Issuer
+		}
+		if iss == issuer {
+			return p, nil
+		}
+	}
+	return Party{}, ErrPartyNotFound
+}
+
+// GetRoles returns the distinct roles declared by the Contract Parties.
+func (c *Contract) GetRoles() []string {
+	var roles []string
+	for _, p := range c.Parties {
+		if !slices.Contains(roles, p.Role) {
+			roles = append(roles, p.Role)
+		}
+	}
+	return roles
+}
+
+// PartyFromEvent returns the Party acting through a CloudEvent. The Party is identified by the
+// PartyExtension attribute, or otherwise by the event subject matching a Party OIDC Subject. A nil
+// Party is returned if the event does not identify one.
+//
+// The event attributes are not authenticated. The Reconciler only lets the Party of an authenticated event
+// act, see WithEventAuthenticators, unless it is created WithUnauthenticatedParties.
+func (c *Contract) PartyFromEvent(event cloudevents.Event) (*Party, error) {
+	if v, ok := event.Extensions()[PartyExtension]; ok {
+		id, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s extension", PartyExtension)
+		}
+		p, err := c.GetParty(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, id)
+		}
+		return &p, nil
+	}
+	if event.Subject() != "" {
+		for _, p := range c.Parties {
+			if p.Subject == event.Subject() {
+				return &p, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// ValidateParties validates that Party IDs are unique, and that every role referenced by a
+// Transition Condition is declared by a Party.
+func (c *Contract) ValidateParties() error {
+	seen := make(map[string]bool)
+	for _, p := range c.Parties {
+		if seen[p.ID] {
+			return fmt.Errorf("%w: %s", ErrDuplicateParty, p.ID)
+		}
+		seen[p.ID] = true
+	}
+
+	roles := c.GetRoles()
+	for _, s := range c.States() {
+		for _, t := range s.Transitions {
+			for _, top := range t.Conditions {
+				for _, cond := range top.flatten() {
+					for _, r := range cond.Roles {
+						if !slices.Contains(roles, r) {
+							return fmt.Errorf("%w: %s (state %s, transition %s)", ErrRoleNotDeclared, r, s.Name, t.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
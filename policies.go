@@ -3,11 +3,21 @@ package slc
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/fs"
 	"log/slog"
+	"maps"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/goccy/go-yaml"
 	gogithub "github.com/google/go-github/v69/github"
 	"github.com/open-policy-agent/opa/v1/rego"
 )
@@ -22,47 +32,279 @@ func WithVars(variables []Variables) PolicyOptions {
 	}
 }
 
-// getPolicyDirectory downloads a directory of OPA Rego policy files from a GitHub repository.
-//
-//nolint:unused
-func getPolicyDirectory(uri, branch, token string) (map[string][]byte, error) {
-	ctx := context.Background()
-	c := NewGitHubClient(token)
+const (
+	// PolicyDataFile is the name of the data documents of a policy directory.
+	PolicyDataFile = "data.json"
+
+	// maxRateLimitRetries is the number of times a GitHub API call is retried when a rate limit is exceeded.
+	maxRateLimitRetries = 3
+	// maxRateLimitWait is the longest wait for a GitHub rate limit to reset before a call is retried.
+	maxRateLimitWait = time.Minute
+)
+
+// PolicyDirectory is the content of a directory of OPA policies: its Rego modules and data documents, keyed by
+// path. Paths are relative to the policy directory, whether downloaded from a Git repository or read from the
+// file system, as for the Path of a Condition.
+type PolicyDirectory struct {
+	// Modules are the Rego modules of the directory, including test modules.
+	Modules map[string][]byte
+	// Data are the data documents of the directory, declared in "data.json" files.
+	Data map[string][]byte
+}
+
+func newPolicyDirectory() *PolicyDirectory {
+	return &PolicyDirectory{Modules: make(map[string][]byte), Data: make(map[string][]byte)}
+}
+
+// add adds a file of the directory if it is a Rego module or data document.
+func (d *PolicyDirectory) add(p string, content []byte) {
+	switch {
+	case path.Ext(p) == ".rego":
+		d.Modules[p] = content
+	case path.Base(p) == PolicyDataFile:
+		d.Data[p] = content
+	}
+}
+
+// policyPath normalizes the Path of a Condition to the path of its policy file in a PolicyDirectory.
+func policyPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+// isPolicyFile determines if a file of a policy directory is a Rego module or data document.
+func isPolicyFile(p string) bool {
+	return path.Ext(p) == ".rego" || path.Base(p) == PolicyDataFile
+}
+
+// RegoModules returns a rego.Module option for each Rego module of the directory in path order, except for test
+// modules and the given paths. Policies evaluated with the modules can import the packages they declare, e.g.,
+// shared helper packages.
+func (d *PolicyDirectory) RegoModules(except ...string) []func(*rego.Rego) {
+	var modules []func(*rego.Rego)
+	for _, p := range slices.Sorted(maps.Keys(d.Modules)) {
+		if strings.HasSuffix(p, "_test.rego") || slices.Contains(except, p) {
+			continue
+		}
+		modules = append(modules, rego.Module(p, string(d.Modules[p])))
+	}
+	return modules
+}
+
+// Documents returns the data documents of the directory merged into a single document. The documents of a
+// "data.json" file are nested under the path of its directory, e.g., "thresholds/data.json" under "thresholds".
+func (d *PolicyDirectory) Documents() (map[string]interface{}, error) {
+	documents := make(map[string]interface{})
+	for _, p := range slices.Sorted(maps.Keys(d.Data)) {
+		var doc interface{}
+		if err := json.Unmarshal(d.Data[p], &doc); err != nil {
+			return nil, fmt.Errorf("invalid data document %s: %w", p, err)
+		}
+		for _, key := range slices.Backward(strings.Split(path.Dir(p), "/")) {
+			if key != "." && key != "" {
+				doc = map[string]interface{}{key: doc}
+			}
+		}
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid data document %s: not an object", p)
+		}
+		mergeDocuments(documents, object)
+	}
+	return documents, nil
+}
 
+// mergeDocuments merges the objects of src into dst. Values of src replace the values of dst that are not objects.
+func mergeDocuments(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcObject, ok := value.(map[string]interface{})
+		dstObject, isObject := dst[key].(map[string]interface{})
+		if ok && isObject {
+			mergeDocuments(dstObject, srcObject)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// policyData merges the Data files of a PolicySource and the PolicyData of the FSMOptions, in that order, into
+// data documents.
+func policyData(ctx context.Context, documents map[string]interface{}, source PolicySource, options *FSMOptions) (map[string]interface{}, error) {
+	for _, src := range source.Data {
+		content, err := getPolicyDataFile(ctx, source, options, src.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve policy data %s: %w", src.Path, err)
+		}
+		if getFileType(src.Path) == YAML {
+			if content, err = yaml.YAMLToJSON(content); err != nil {
+				return nil, fmt.Errorf("invalid policy data %s: %w", src.Path, err)
+			}
+		}
+		var doc interface{}
+		if err = json.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("invalid policy data %s: %w", src.Path, err)
+		}
+		prefix := strings.FieldsFunc(src.Prefix, func(r rune) bool { return r == '.' || r == '/' })
+		for _, key := range slices.Backward(prefix) {
+			doc = map[string]interface{}{key: doc}
+		}
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid policy data %s: not an object", src.Path)
+		}
+		mergeDocuments(documents, object)
+	}
+
+	// The policy data is converted to JSON values, as the data files.
+	if len(options.PolicyData) > 0 {
+		content, err := json.Marshal(options.PolicyData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid policy data: %w", err)
+		}
+		var object map[string]interface{}
+		if err = json.Unmarshal(content, &object); err != nil {
+			return nil, err
+		}
+		mergeDocuments(documents, object)
+	}
+	return documents, nil
+}
+
+// getPolicyDataFile retrieves a data file of a PolicySource from an HTTP(S) URL, or otherwise as the policy files
+// of Conditions: from the FilesystemPath of the FSMOptions if set, or from the Git repository of the PolicySource.
+func getPolicyDataFile(ctx context.Context, source PolicySource, options *FSMOptions, p string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(p, "http://"), strings.HasPrefix(p, "https://"):
+		return fetchDocument(ctx, p)
+	case options.FilesystemPath != "":
+		return os.ReadFile(filepath.Join(options.FilesystemPath, p))
+	default:
+		return getPolicyFile(ctx, source.URL, source.Branch, options.GitHubPAT, p)
+	}
+}
+
+// getPolicyDirectory downloads the Rego modules and data documents of a directory of a GitHub repository, and
+// of its subdirectories.
+func getPolicyDirectory(ctx context.Context, uri, branch, token, directory string) (*PolicyDirectory, error) {
 	owner, repo, err := parseGitHubURL(uri)
 	if err != nil {
 		return nil, err
 	}
+	return downloadPolicyDirectory(ctx, NewGitHubClient(token), owner, repo, branch, directory)
+}
+
+func downloadPolicyDirectory(ctx context.Context, c *gogithub.Client, owner, repo, branch, directory string) (*PolicyDirectory, error) {
 	opts := &gogithub.RepositoryContentGetOptions{
 		Ref: branch,
 	}
-	_, directoryContent, resp, err := c.Repositories.GetContents(ctx, owner, repo, uri, opts)
-	if err != nil {
-		return nil, err
-	}
+	d := newPolicyDirectory()
+	root := strings.Trim(directory, "/")
+	queue := []string{root}
+	for len(queue) > 0 {
+		dir := queue[0]
+		queue = queue[1:]
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, err
-	}
+		var entries []*gogithub.RepositoryContent
+		err := retryRateLimit(ctx, func() (*gogithub.Response, error) {
+			var resp *gogithub.Response
+			var err error
+			_, entries, resp, err = c.Repositories.GetContents(ctx, owner, repo, dir, opts)
+			return resp, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list policy directory %s: %w", dir, err)
+		}
 
-	filesContent := make(map[string][]byte)
-	for _, file := range directoryContent {
-		if *file.Type == "file" {
-			fileContent, _, _, err := c.Repositories.GetContents(ctx, owner, repo, *file.Path, opts)
-			if err != nil {
-				return nil, err
-			}
-			if fileContent != nil {
-				decodedContent, err := fileContent.GetContent()
+		for _, entry := range entries {
+			switch entry.GetType() {
+			case "dir":
+				queue = append(queue, entry.GetPath())
+			case "file":
+				if !isPolicyFile(entry.GetPath()) {
+					continue
+				}
+				var file *gogithub.RepositoryContent
+				err := retryRateLimit(ctx, func() (*gogithub.Response, error) {
+					var resp *gogithub.Response
+					var err error
+					file, _, resp, err = c.Repositories.GetContents(ctx, owner, repo, entry.GetPath(), opts)
+					return resp, err
+				})
+				if err != nil {
+					return nil, fmt.Errorf("failed to download policy file %s: %w", entry.GetPath(), err)
+				}
+				content, err := file.GetContent()
 				if err != nil {
 					return nil, err
 				}
-				filesContent[*file.Path] = []byte(decodedContent)
+				p := entry.GetPath()
+				if root != "" {
+					p = strings.TrimPrefix(p, root+"/")
+				}
+				d.add(p, []byte(content))
 			}
 		}
 	}
+	return d, nil
+}
+
+// retryRateLimit calls the GitHub API, and retries the call when a primary or secondary rate limit is exceeded
+// once the limit resets. The call is not retried if the limit resets after maxRateLimitWait.
+func retryRateLimit(ctx context.Context, call func() (*gogithub.Response, error)) error {
+	for attempt := 0; ; attempt++ {
+		_, err := call()
+		var wait time.Duration
+		var rateLimit *gogithub.RateLimitError
+		var abuseRateLimit *gogithub.AbuseRateLimitError
+		switch {
+		case errors.As(err, &rateLimit):
+			wait = time.Until(rateLimit.Rate.Reset.Time)
+		case errors.As(err, &abuseRateLimit):
+			wait = abuseRateLimit.GetRetryAfter()
+		default:
+			return err
+		}
+		if attempt == maxRateLimitRetries || wait > maxRateLimitWait {
+			return err
+		}
+
+		timer := time.NewTimer(max(wait, 0))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// loadPolicies retrieves the Rego modules and data documents of a PolicySource. As for the policy files of
+// Conditions, the files are read from the FilesystemPath of the FSMOptions if set, or are otherwise read from
+// the Bundle of the PolicySource, or downloaded from the directory of its Git repository.
+func loadPolicies(ctx context.Context, source PolicySource, options *FSMOptions) (*PolicyDirectory, error) {
+	if options.FilesystemPath == "" && source.Bundle != "" {
+		return loadBundle(ctx, source)
+	}
+	if options.FilesystemPath == "" {
+		return getPolicyDirectory(ctx, source.URL, source.Branch, options.GitHubPAT, source.Directory)
+	}
 
-	return filesContent, nil
+	d := newPolicyDirectory()
+	err := filepath.WalkDir(options.FilesystemPath, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || !isPolicyFile(p) {
+			return err
+		}
+		content, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(options.FilesystemPath, p)
+		if err != nil {
+			return err
+		}
+		d.add(filepath.ToSlash(rel), content)
+		return nil
+	})
+	return d, err
 }
 
 // getPolicyFile downloads a single OPA Rego policy file from a GitHub repository.
@@ -118,25 +360,31 @@ func NewRegoPolicyFS(ctx context.Context, module, query, path string) (*rego.Pre
 }
 
 // NewRegoPolicy prepares an OPA Rego policy for evaluation that can be used within Contract State Condition.
-func NewRegoPolicy(ctx context.Context, module, query string, policyContent []byte, variables []Variables, logger *slog.Logger) (*rego.PreparedEvalQuery, error) {
-	var modifiedPolicy []byte
-	if len(variables) > 0 {
-		for _, variable := range variables {
-			if variable.Name == "" || variable.Type == "" {
-				return nil, errors.New("variable name and type must be specified")
-			}
-			content := overridePolicyValue(policyContent, "$"+variable.Name, variable.Default, logger)
-			modifiedPolicy = content
+// The "$name" placeholders of every Variable in the policy are replaced by its declared Default, never by its
+// current Value, which may originate from event data. Longer names are replaced first, so that "$amount" does
+// not replace the start of "$amountMax".
+// Additional options, e.g., the modules of PolicyDirectory.RegoModules compiled along with the policy, or the
+// rego.Store of its data documents, are applied to the query.
+func NewRegoPolicy(ctx context.Context, module, query string, policyContent []byte, variables []Variables, logger *slog.Logger, options ...func(*rego.Rego)) (*rego.PreparedEvalQuery, error) {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	for _, variable := range variables {
+		if variable.Name == "" || variable.Type == "" {
+			return nil, errors.New("variable name and type must be specified")
 		}
 	}
-	if len(modifiedPolicy) != 0 {
-		policyContent = modifiedPolicy
+	ordered := slices.SortedStableFunc(slices.Values(variables), func(a, b Variables) int {
+		return len(b.Name) - len(a.Name)
+	})
+	for _, variable := range ordered {
+		policyContent = overridePolicyValue(policyContent, "$"+variable.Name, variable.Default, logger)
 	}
 
-	r := rego.New(
+	r := rego.New(append([]func(*rego.Rego){
 		rego.Query(query),
 		rego.Module(module, string(policyContent)),
-	)
+	}, options...)...)
 
 	compiled, err := r.PrepareForEval(ctx)
 	if err != nil {
@@ -0,0 +1,272 @@
+package slc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gogithub "github.com/google/go-github/v69/github"
+	"github.com/open-policy-agent/opa/v1/rego"
+)
+
+func TestDownloadPolicyDirectory(t *testing.T) {
+	files := map[string]string{
+		"policies/order.rego":        "./tests/policytests/pass/order.rego",
+		"policies/order_test.rego":   "./tests/policytests/pass/order_test.rego",
+		"policies/lib/amounts.rego":  "./tests/policytests/pass/lib/amounts.rego",
+		"policies/lib/data.json":     "",
+		"policies/README.md":         "",
+		"policies/lib/amounts.yaml":  "",
+		"policies/nested/deep/.keep": "",
+	}
+	directories := map[string][]map[string]string{
+		"policies": {
+			{"type": "file", "path": "policies/order.rego"},
+			{"type": "file", "path": "policies/order_test.rego"},
+			{"type": "file", "path": "policies/README.md"},
+			{"type": "dir", "path": "policies/lib"},
+			{"type": "dir", "path": "policies/nested"},
+		},
+		"policies/lib": {
+			{"type": "file", "path": "policies/lib/amounts.rego"},
+			{"type": "file", "path": "policies/lib/amounts.yaml"},
+			{"type": "file", "path": "policies/lib/data.json"},
+		},
+		"policies/nested":      {{"type": "dir", "path": "policies/nested/deep"}},
+		"policies/nested/deep": {{"type": "file", "path": "policies/nested/deep/.keep"}},
+	}
+
+	// The first download of a file exceeds the secondary rate limit.
+	var limited atomic.Bool
+	downloaded := make(map[string]int)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := strings.TrimPrefix(r.URL.Path, "/repos/myorg/myrepo/contents/")
+		if r.URL.Query().Get("ref") != "main" {
+			http.NotFound(w, r)
+			return
+		}
+		if entries, ok := directories[p]; ok {
+			_ = json.NewEncoder(w).Encode(entries)
+			return
+		}
+		source, ok := files[p]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if !limited.Swap(true) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusForbidden)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"message":           "You have exceeded a secondary rate limit.",
+				"documentation_url": "https://docs.github.com/rest/overview/rate-limits-for-the-rest-api#about-secondary-rate-limits",
+			})
+			return
+		}
+		downloaded[p]++
+		content := []byte(`{"limits": {"small": 1000}}`)
+		if source != "" {
+			var err error
+			if content, err = os.ReadFile(source); err != nil {
+				t.Error(err)
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"type":     "file",
+			"path":     p,
+			"encoding": "base64",
+			"content":  base64.StdEncoding.EncodeToString(content),
+		})
+	}))
+	defer srv.Close()
+
+	c := gogithub.NewClient(srv.Client())
+	c.BaseURL, _ = url.Parse(srv.URL + "/")
+	d, err := downloadPolicyDirectory(context.Background(), c, "myorg", "myrepo", "main", "/policies/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only Rego modules and data documents are downloaded, each once.
+	// Paths are relative to the policy directory.
+	expected := []string{"lib/amounts.rego", "order.rego", "order_test.rego"}
+	if !equalSlices(slices.Sorted(maps.Keys(d.Modules)), expected) {
+		t.Fatalf("expected modules %v, got %v", expected, slices.Sorted(maps.Keys(d.Modules)))
+	}
+	if !equalSlices(slices.Sorted(maps.Keys(d.Data)), []string{"lib/data.json"}) {
+		t.Fatalf("unexpected data %v", slices.Sorted(maps.Keys(d.Data)))
+	}
+	for p, n := range downloaded {
+		if n != 1 {
+			t.Fatalf("expected %s to be downloaded once, got %d", p, n)
+		}
+	}
+
+	// Policies can import the packages of the other modules, except for test modules.
+	if modules := d.RegoModules("order.rego"); len(modules) != 1 {
+		t.Fatalf("expected 1 module, got %d", len(modules))
+	}
+	query, err := NewRegoPolicy(context.Background(), "order", "data.order.small.allow", d.Modules["order.rego"], nil, nil, d.RegoModules("order.rego")...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := query.Eval(context.Background(), rego.EvalInput(map[string]interface{}{"amount": 500}))
+	if err != nil || !results.Allowed() {
+		t.Fatalf("expected the policy to allow, got %v, %v", results, err)
+	}
+
+	// The data documents are mounted at the same path as those of the directory on the file system.
+	dir := t.TempDir()
+	if err = os.MkdirAll(filepath.Join(dir, "lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, "lib", "data.json"), []byte(`{"limits": {"small": 1000}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	local, err := loadPolicies(context.Background(), PolicySource{}, &FSMOptions{FilesystemPath: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, directory := range []*PolicyDirectory{d, local} {
+		documents, err := directory.Documents()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := json.Marshal(documents)
+		if expected := `{"lib":{"limits":{"small":1000}}}`; string(content) != expected {
+			t.Fatalf("expected %s, got %s", expected, content)
+		}
+	}
+}
+
+func TestStateMachinePolicyImports(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.State.States[0].Transitions[0].Conditions = []Condition{{Name: "small.order", Path: "order.rego", Value: "data.order.small.allow"}}
+
+	// The policy file imports the package of another module of the policy directory.
+	sm, err := NewStateMachine(ctx, "Draft", c, WithFSPolicyFiles("./tests/policytests/pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, amount := range []int{5000, 500} {
+		input := TransitionCtx{Input: map[string]interface{}{"amount": amount}}
+		err = sm.FireCtx(NewTransitionContext(ctx, &input), "com.decombine.order.approve")
+		if amount > 1000 && err == nil {
+			t.Fatalf("expected amount %d to be denied", amount)
+		}
+		if amount <= 1000 && err != nil {
+			t.Fatalf("expected amount %d to be allowed, got %v", amount, err)
+		}
+	}
+}
+
+func TestStateMachinePolicyData(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Policy.Data = []PolicyDataSource{{Path: "thresholds.yaml", Prefix: "thresholds"}}
+	c.State.States[0].Transitions[0].Conditions = []Condition{{
+		Name:  "within.threshold",
+		Rego:  "package order\n\nallow if {\n\tinput.amount >= data.limits.min_amount\n\tinput.amount <= data.thresholds.max_amount\n}",
+		Value: "data.order.allow",
+	}}
+
+	tests := []struct {
+		name    string
+		opts    []FSMOption
+		allowed map[int]bool
+	}{
+		{
+			// The data documents of the policy directory are merged with the data file.
+			name:    "data file",
+			allowed: map[int]bool{5: false, 500: true, 5000: false},
+		},
+		{
+			name:    "policy data",
+			opts:    []FSMOption{WithPolicyData(map[string]interface{}{"thresholds": map[string]int{"max_amount": 10000}})},
+			allowed: map[int]bool{5000: true, 50000: false},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for amount, allowed := range test.allowed {
+				opts := append([]FSMOption{WithFSPolicyFiles("./tests/policydata")}, test.opts...)
+				sm, err := NewStateMachine(ctx, "Draft", c, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				input := TransitionCtx{Input: map[string]interface{}{"amount": amount}}
+				err = sm.FireCtx(NewTransitionContext(ctx, &input), "com.decombine.order.approve")
+				if allowed != (err == nil) {
+					t.Fatalf("expected amount %d allowed: %t, got %v", amount, allowed, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyData(t *testing.T) {
+	ctx := context.Background()
+	source := PolicySource{Data: []PolicyDataSource{{Path: "thresholds.yaml", Prefix: "order.thresholds"}}}
+	options := &FSMOptions{FilesystemPath: "./tests/policydata", PolicyData: map[string]interface{}{"order": map[string]interface{}{"currency": "EUR"}}}
+
+	documents, err := policyData(ctx, map[string]interface{}{"order": map[string]interface{}{"region": "EU"}}, source, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := json.Marshal(documents)
+	expected := `{"order":{"currency":"EUR","region":"EU","thresholds":{"approvers":["finance","legal"],"max_amount":1000}}}`
+	if string(content) != expected {
+		t.Fatalf("expected %s, got %s", expected, content)
+	}
+
+	// Policy tests see the same data documents.
+	results, err := TestPolicies(ctx, PolicySource{Data: []PolicyDataSource{{Path: "thresholds.yaml", Prefix: "thresholds"}}}, WithFSPolicyFiles("./tests/policydata"))
+	if err != nil {
+		t.Fatalf("%v: %v", err, results)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %v", results)
+	}
+}
+
+func TestConditionGuardDataRetry(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "policies")
+	guard := &conditionGuard{contract: &Contract{}, options: &FSMOptions{FilesystemPath: dir}}
+
+	// A failed load is not cached.
+	if _, err := guard.data(ctx); err == nil {
+		t.Fatal("expected the missing policy directory to fail")
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "limits"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "limits", "data.json"), []byte(`{"min_amount": 10}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	documents, err := guard.data(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := json.Marshal(documents)
+	if expected := `{"limits":{"min_amount":10}}`; string(content) != expected {
+		t.Fatalf("expected %s, got %s", expected, content)
+	}
+}
@@ -0,0 +1,114 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/open-policy-agent/opa/v1/ast"
+	"github.com/open-policy-agent/opa/v1/storage/inmem"
+	"github.com/open-policy-agent/opa/v1/tester"
+)
+
+var (
+	ErrPolicyTestsFailed = errors.New("policy tests failed")
+)
+
+// PolicyTestResult is the result of an OPA policy test, a rule prefixed with "test_".
+type PolicyTestResult struct {
+	// The Package of the test. E.g., "data.order.small_test"
+	Package string `json:"package"`
+	// The Name of the test rule. E.g., "test_small_order_allowed"
+	Name string `json:"name"`
+	// The File declaring the test, relative to the policy directory
+	File string `json:"file"`
+	// The Row of the test in its File
+	Row int `json:"row"`
+	// Pass indicates the test succeeded.
+	Pass bool `json:"pass"`
+	// Skip indicates the test is skipped, as its name is prefixed with "todo_".
+	Skip bool `json:"skip,omitempty"`
+	// Error is the error evaluating the test, if any.
+	Error string `json:"error,omitempty"`
+	// Duration of the test
+	Duration time.Duration `json:"duration"`
+}
+
+func (r PolicyTestResult) String() string {
+	outcome := "PASS"
+	switch {
+	case r.Skip:
+		outcome = "SKIPPED"
+	case r.Error != "":
+		outcome = "ERROR: " + r.Error
+	case !r.Pass:
+		outcome = "FAIL"
+	}
+	return fmt.Sprintf("%s.%s: %s", r.Package, r.Name, outcome)
+}
+
+// TestPolicies runs the OPA policy tests of a PolicySource, declared in its "_test.rego" files, with the OPA
+// test runner. The policies are loaded as for the FSM: from the file system with WithFSPolicyFiles, or from the
+// Bundle or directory of the Git repository of the PolicySource. The data documents of the policies, the Data files
+// of the PolicySource and WithPolicyData are available to the tests. The results are returned in the order of the files and
+// rules declaring them. ErrPolicyTestsFailed is returned along with the results if any test fails.
+func TestPolicies(ctx context.Context, source PolicySource, opts ...FSMOption) ([]PolicyTestResult, error) {
+	options := &FSMOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	policies, err := loadPolicies(ctx, source, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policies: %w", err)
+	}
+	modules := make(map[string]*ast.Module, len(policies.Modules))
+	for name, content := range policies.Modules {
+		if modules[name], err = ast.ParseModuleWithOpts(name, string(content), ast.ParserOptions{RegoVersion: ast.RegoV1}); err != nil {
+			return nil, err
+		}
+	}
+
+	// The tests see the same data documents as the policies evaluated by the FSM.
+	documents, err := policies.Documents()
+	if err != nil {
+		return nil, err
+	}
+	if documents, err = policyData(ctx, documents, source, options); err != nil {
+		return nil, err
+	}
+	store := inmem.NewFromObject(documents)
+
+	ch, err := tester.NewRunner().SetModules(modules).SetStore(store).RunTests(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	var results []PolicyTestResult
+	var failed []string
+	for r := range ch {
+		result := PolicyTestResult{
+			Package:  r.Package,
+			Name:     r.Name,
+			Pass:     r.Pass(),
+			Skip:     r.Skip,
+			Duration: r.Duration,
+		}
+		if r.Location != nil {
+			result.File, result.Row = r.Location.File, r.Location.Row
+		}
+		if r.Error != nil {
+			result.Error = r.Error.Error()
+		}
+		if !result.Pass && !result.Skip {
+			failed = append(failed, result.Package+"."+result.Name)
+		}
+		results = append(results, result)
+	}
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return results, fmt.Errorf("%w: %v", ErrPolicyTestsFailed, failed)
+	}
+	return results, nil
+}
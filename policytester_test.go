@@ -0,0 +1,38 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTestPolicies(t *testing.T) {
+	ctx := context.Background()
+	source := PolicySource{URL: "https://github.com/myorg/myrepo", Directory: "/policies"}
+
+	results, err := TestPolicies(ctx, source, WithFSPolicyFiles("./tests/policytests/pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected 3 results, got %v", results)
+	}
+	for _, r := range results {
+		if r.File != "order_test.rego" || r.Package != "data.order.small_test" {
+			t.Fatalf("unexpected result %+v", r)
+		}
+		// Tests prefixed with "todo_" are skipped.
+		skip := r.Name == "todo_test_flagged_order_denied"
+		if r.Skip != skip || !skip && !r.Pass {
+			t.Fatalf("unexpected result %s", r)
+		}
+	}
+
+	results, err = TestPolicies(ctx, source, WithFSPolicyFiles("./tests/policytests/fail"))
+	if !errors.Is(err, ErrPolicyTestsFailed) {
+		t.Fatalf("expected %v, got %v", ErrPolicyTestsFailed, err)
+	}
+	if len(results) != 2 || !results[0].Pass || results[1].Pass || results[1].Name != "test_limit_allowed" {
+		t.Fatalf("unexpected results %v", results)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"log/slog"
+	"sync"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/cloudevents/sdk-go/v2/protocol/http"
@@ -32,21 +33,52 @@ type ReconcilerConfig struct {
 }
 
 type Reconciler struct {
-	Config         ReconcilerConfig
-	EventChannel   chan *cloudevents.Event
-	Consumer       jetstream.Consumer
-	Stream         jetstream.JetStream
-	Contract       *Contract
-	FSM            *stateless.StateMachine
+	Config       ReconcilerConfig
+	EventChannel chan *cloudevents.Event
+	Consumer     jetstream.Consumer
+	Stream       jetstream.JetStream
+	Contract     *Contract
+	FSM          *stateless.StateMachine
+	// Regions are the FSMs of the Regions of the StateConfiguration, keyed by Region name.
+	Regions        map[string]*stateless.StateMachine
 	Client         client.Client
 	CloudEventOpts []http.Option
 	Logger         *slog.Logger
+	// Authenticators verify event producers before events can trigger Transitions. If empty,
+	// events are not authenticated, and do not act on behalf of a Party.
+	Authenticators []EventAuthenticator
+	// Scheduler fires the timed Transitions of the current State.
+	Scheduler *Scheduler
+	// Models are the Concerto models event data is validated against. If nil, the Models of the
+	// Contract are loaded when the Reconciler starts.
+	Models *ConcertoModels
+
+	timerStore   TimerStore
+	clock        Clock
+	timerChannel chan Timer
+	schemas      sync.Map
+	messages     jetstream.MessagesContext
+	done         chan struct{}
+	doneOnce     sync.Once
+	// observer observes the Transitions fired, or denied, during a simulation. It is nil otherwise.
+	observer transitionObserver
+	// unauthenticatedParties trusts the event attributes to identify the acting Party.
+	unauthenticatedParties bool
+	// mu serializes the consumption of Events and Timers with amendments of the SLC.
+	mu sync.Mutex
 }
 
 type ReconcilerOptions struct {
 	client         client.Client
 	logger         *slog.Logger
 	cloudEventOpts []http.Option
+	authenticators []EventAuthenticator
+	timerStore     TimerStore
+	clock          Clock
+	regions        map[string]*stateless.StateMachine
+	models         *ConcertoModels
+
+	unauthenticatedParties bool
 }
 
 func WithKubernetesClient(client client.Client) ReconcilerOptions {
@@ -67,6 +99,54 @@ func WithCloudEventReceiverOpts(ceOpts ...http.Option) ReconcilerOptions {
 	}
 }
 
+// WithEventAuthenticators requires events to be authenticated by one of the given EventAuthenticators
+// before they can trigger a Transition. Verified claims are passed to guards through the TransitionCtx.
+func WithEventAuthenticators(authenticators ...EventAuthenticator) ReconcilerOptions {
+	return ReconcilerOptions{
+		authenticators: authenticators,
+	}
+}
+
+// WithUnauthenticatedParties trusts the PartyExtension attribute or subject of events to identify the acting
+// Party when the event is not authenticated. Anyone able to produce events can then act as any Party, which is
+// only suitable for development and simulations.
+func WithUnauthenticatedParties() ReconcilerOptions {
+	return ReconcilerOptions{
+		unauthenticatedParties: true,
+	}
+}
+
+// WithTimerStore persists the Timers of timed Transitions in the given TimerStore so that they survive
+// a restart of the Reconciler. Timers are held in memory by default.
+func WithTimerStore(store TimerStore) ReconcilerOptions {
+	return ReconcilerOptions{
+		timerStore: store,
+	}
+}
+
+// WithClock replaces the Clock used to schedule timed Transitions. This is useful for testing.
+func WithClock(clock Clock) ReconcilerOptions {
+	return ReconcilerOptions{
+		clock: clock,
+	}
+}
+
+// WithRegions provides the FSMs of the Regions of the StateConfiguration, keyed by Region name. Each
+// Region of the Contract requires an FSM, constructed with NewRegionStateMachine.
+func WithRegions(regions map[string]*stateless.StateMachine) ReconcilerOptions {
+	return ReconcilerOptions{
+		regions: regions,
+	}
+}
+
+// WithConcertoModels provides the Concerto models event data is validated against, in place of loading
+// the Models of the Contract.
+func WithConcertoModels(models *ConcertoModels) ReconcilerOptions {
+	return ReconcilerOptions{
+		models: models,
+	}
+}
+
 func NewReconciler(c *Contract, fsm *stateless.StateMachine, consumer jetstream.Consumer, stream jetstream.JetStream,
 	config ReconcilerConfig, options ...ReconcilerOptions) *Reconciler {
 
@@ -80,24 +160,47 @@ func NewReconciler(c *Contract, fsm *stateless.StateMachine, consumer jetstream.
 			r.Config.UseCloudEventReceiver = true
 			r.CloudEventOpts = o.cloudEventOpts
 		}
+		if o.authenticators != nil {
+			r.Authenticators = append(r.Authenticators, o.authenticators...)
+		}
+		if o.timerStore != nil {
+			r.timerStore = o.timerStore
+		}
+		if o.clock != nil {
+			r.clock = o.clock
+		}
+		if o.regions != nil {
+			r.Regions = o.regions
+		}
+		if o.models != nil {
+			r.Models = o.models
+		}
+		if o.unauthenticatedParties {
+			r.unauthenticatedParties = true
+		}
 		if o.logger != nil {
 			r.Logger = o.logger
-		} else {
-			level := slog.LevelInfo
-			if config.LogLevel != 0 {
-				level = config.LogLevel
-			}
-			r.Logger = slog.New(slog.NewTextHandler(log.Writer(), &slog.HandlerOptions{
-				Level: level,
-			}))
 		}
 	}
 
+	if r.Logger == nil {
+		level := slog.LevelInfo
+		if config.LogLevel != 0 {
+			level = config.LogLevel
+		}
+		r.Logger = slog.New(slog.NewTextHandler(log.Writer(), &slog.HandlerOptions{
+			Level: level,
+		}))
+	}
+
 	r.Contract = c
 	r.FSM = fsm
 	r.Consumer = consumer
 	r.Stream = stream
 	r.Config = config
+	r.timerChannel = make(chan Timer)
+	r.done = make(chan struct{})
+	r.Scheduler = NewScheduler(r.timerStore, r.clock, r.fireTimer)
 
 	return &r
 }
@@ -112,39 +215,72 @@ func (r *Reconciler) Start(ctx context.Context) error {
 
 	r.Logger.Info("Contract", "Contract", r.Contract.Name, "State", state.Name)
 
-	eligible, err := r.eligibleTransitions(ctx)
-	if err != nil {
+	if state.Final {
+		r.Logger.Info("Smart Legal Contract is in a final state. No events will be consumed.")
+		return nil
+	}
+
+	if r.Models == nil && len(r.Contract.Models) > 0 {
+		if r.Models, err = LoadConcertoModels(ctx, r.Contract.Models, nil); err != nil {
+			return fmt.Errorf("failed to load concerto models: %w", err)
+		}
+	}
+	if r.Models != nil {
+		if err = r.Models.ValidateContract(r.Contract); err != nil {
+			return fmt.Errorf("failed to validate contract against concerto models: %w", err)
+		}
+	}
+
+	if _, err = r.eligibleTransitions(ctx); err != nil {
 		return fmt.Errorf("failed to get eligible transitions: %w", err)
 	}
 
-	if r.Client != nil {
-		r.Logger.Info("Smart Legal Contract connected to Kubernetes API. Synchronizing any Entry workloads.")
-		err := r.reconcileAction(ctx, state.Entry)
+	// The current State of the StateConfiguration and of each Region is active, together with their
+	// superstates.
+	var active []State
+	for _, region := range r.regions() {
+		states, err := r.activeStates(ctx, region)
 		if err != nil {
-			return fmt.Errorf("failed to reconcile entry actions: %w", err)
+			return err
 		}
+		active = append(active, states...)
 	}
+	r.updateStatus(ctx)
 
-	// Register a cloudevent to be published to the Stream when transitioning
-	// so that other services can listen for state changes.
-	r.FSM.OnTransitioning(func(context.Context, stateless.Transition) {
-		evt, err := r.Contract.CreateEvent("com.decombine.slc.transitioning", "decombine")
-		if err != nil {
-			r.Logger.Error("Error creating transitioning event", "error", err)
-			return
+	// Schedule the timed Transitions of the active States. Timers persisted before a restart keep
+	// their original due time.
+	for _, s := range active {
+		if err = r.schedule(ctx, s); err != nil {
+			return fmt.Errorf("failed to schedule timed transitions: %w", err)
 		}
-		payload, _ := evt.MarshalJSON()
-		if r.Config.PublishSubject != "" {
-			publish, err := r.Stream.Publish(ctx, r.Config.PublishSubject, payload)
-			if err != nil {
-				r.Logger.Error("Error publishing transitioning event", "error", err)
-				return
+	}
+	go func() {
+		if err := r.Scheduler.Run(ctx); err != nil {
+			r.Logger.Error("Error running timer scheduler", "error", err)
+		}
+	}()
+
+	if r.Client != nil {
+		r.Logger.Info("Smart Legal Contract connected to Kubernetes API. Synchronizing any Entry workloads.")
+		for _, s := range active {
+			if err := r.reconcileAction(ctx, s.Entry); err != nil {
+				return fmt.Errorf("failed to reconcile entry actions: %w", err)
 			}
-			r.Logger.Info("Published transitioning event", "publish", publish)
-		} else {
-			r.Logger.Info("No publish subject configured. Skipping publishing transitioning event.")
 		}
-	})
+	}
+
+	// Register a cloudevent to be published to the Stream when transitioning
+	// so that other services can listen for state changes.
+	onTransitioning := r.transitioning(ctx)
+	r.FSM.OnTransitioning(onTransitioning)
+	for _, sm := range r.Regions {
+		sm.OnTransitioning(onTransitioning)
+	}
+
+	// Regions may have joined before a restart.
+	if err = r.join(ctx); err != nil {
+		return fmt.Errorf("failed to join regions: %w", err)
+	}
 
 	// Start a goroutine to spawn workers for incoming message processing
 	go func() {
@@ -157,23 +293,47 @@ func (r *Reconciler) Start(ctx context.Context) error {
 		select {
 		case event := <-r.EventChannel:
 			r.Logger.Info("Received event", "type", event.Type(), "source", event.Source(), "id", event.ID())
-			err := r.ConsumeEvent(ctx, event, eligible)
-			if err != nil {
+			if err := r.consumeReceived(ctx, event); err != nil {
 				r.Logger.Error("Error processing event", "error", err)
 				return err
 			}
+		case timer := <-r.timerChannel:
+			r.Logger.Info("Timer fired", "state", timer.State, "transition", timer.Transition, "event", timer.Event)
+			r.mu.Lock()
+			err := r.consumeTimer(ctx, timer)
+			r.mu.Unlock()
+			if err != nil {
+				r.Logger.Error("Error processing timer", "error", err)
+				return err
+			}
+		case <-r.done:
+			return nil
 		case <-ctx.Done():
 			return nil
 		}
 	}
 }
 
+// consumeReceived consumes an Event received by the event loop for the Transitions eligible in the current State.
+func (r *Reconciler) consumeReceived(ctx context.Context, event *cloudevents.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	eligible, err := r.eligibleTransitions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get eligible transitions: %w", err)
+	}
+	return r.ConsumeEvent(ctx, event, eligible)
+}
+
 // run is a blocking function that listens for incoming messages from the JetStream Consumer.
 func (r *Reconciler) run() error {
 	iter, _ := r.Consumer.Messages(jetstream.PullMaxMessages(r.Config.MaxMassages))
+	r.messages = iter
 	numWorkers := r.Config.Workers
 	sem := make(chan struct{}, numWorkers)
-	ctx := context.Background()
+	// The context is cancelled to stop the CloudEvents receiver once the SLC completes.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
 	go func() {
 		if r.Config.UseCloudEventReceiver {
@@ -200,7 +360,11 @@ func (r *Reconciler) run() error {
 	}()
 
 	for {
-		sem <- struct{}{}
+		select {
+		case <-r.done:
+			return nil
+		case sem <- struct{}{}:
+		}
 		go func() {
 			defer func() {
 				<-sem
@@ -229,6 +393,7 @@ func (r *Reconciler) reconcileAction(ctx context.Context, action Action) error {
 					},
 					Spec: *ka.KustomizationSpec,
 				}
+				r.substituteVariables(&k.Spec)
 				if err := r.reconcileKustomization(ctx, k, ka.Namespace); err != nil {
 					return err
 				}
@@ -275,23 +440,55 @@ func (r *Reconciler) receiveJetStream(msg jetstream.Msg) {
 	}()
 }
 
-// eligibleTransitions returns the Transition that can be triggered from the current State.
+// eligibleTransitions returns the Transition that can be triggered from the current State, including
+// the Transitions inherited from its superstates.
 func (r *Reconciler) eligibleTransitions(ctx context.Context) ([]Transition, error) {
 	s, err := r.getState(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Transitions, nil
+	return r.Contract.GetTransitions(s.Name)
 }
 
 // getState returns the current State of the Smart Legal Contract.
 func (r *Reconciler) getState(ctx context.Context) (State, error) {
-	fsmState, err := r.FSM.State(ctx)
+	return r.stateOf(ctx, "")
+}
+
+// transitioning returns the callback publishing a cloudevent to the Stream when the SLC transitions.
+func (r *Reconciler) transitioning(ctx context.Context) func(context.Context, stateless.Transition) {
+	return func(context.Context, stateless.Transition) {
+		evt, err := r.Contract.CreateEvent(TransitioningEventType, "decombine")
+		if err != nil {
+			r.Logger.Error("Error creating transitioning event", "error", err)
+			return
+		}
+		payload, _ := evt.MarshalJSON()
+		if r.Config.PublishSubject != "" {
+			publish, err := r.Stream.Publish(ctx, r.Config.PublishSubject, payload)
+			if err != nil {
+				r.Logger.Error("Error publishing transitioning event", "error", err)
+				return
+			}
+			r.Logger.Info("Published transitioning event", "publish", publish)
+		} else {
+			r.Logger.Info("No publish subject configured. Skipping publishing transitioning event.")
+		}
+	}
+}
+
+// stateOf returns the current State of a Region of the Smart Legal Contract, or of its StateConfiguration for "".
+func (r *Reconciler) stateOf(ctx context.Context, region string) (State, error) {
+	sm, err := r.machine(region)
+	if err != nil {
+		return State{}, err
+	}
+	fsmState, err := sm.State(ctx)
 	if err != nil {
 		return State{}, fmt.Errorf("failed to get current state: %w", err)
 	}
-	for _, s := range r.Contract.State.States {
+	for _, s := range r.Contract.States() {
 		if s.Name == fsmState {
 			return s, nil
 		}
@@ -299,6 +496,24 @@ func (r *Reconciler) getState(ctx context.Context) (State, error) {
 	return State{}, fmt.Errorf("state not found: %s", fsmState)
 }
 
+// activeStates returns the current State of a Region, or of the StateConfiguration for "", preceded by its
+// superstates from the outermost inwards.
+func (r *Reconciler) activeStates(ctx context.Context, region string) ([]State, error) {
+	state, err := r.stateOf(ctx, region)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current state: %w", err)
+	}
+	var active []State
+	for _, name := range r.Contract.Ancestors(state.Name) {
+		s, err := r.Contract.GetState(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get superstate: %w", err)
+		}
+		active = append([]State{s}, active...)
+	}
+	return append(active, state), nil
+}
+
 // checkForExitActions checks if the transitioning State has any Exit Actions to reconcile.
 func (r *Reconciler) checkForExitActions(ctx context.Context, state State) error {
 	if state.Exit.KubernetesActions != nil {
@@ -311,6 +526,7 @@ func (r *Reconciler) checkForExitActions(ctx context.Context, state State) error
 					},
 					Spec: *ka.KustomizationSpec,
 				}
+				r.substituteVariables(&k.Spec)
 				r.Logger.Info("Exiting State", "State", state.Name, "Kustomization", k.Name, "Namespace", k.Namespace)
 				if err := r.reconcileKustomization(ctx, k, ka.Namespace); err != nil {
 					return err
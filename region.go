@@ -0,0 +1,196 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/qmuntal/stateless"
+)
+
+// JoinEventSource is the source of the events emitted by the Reconciler when Regions join.
+const JoinEventSource = "decombine/join"
+
+var (
+	ErrRegionNotFound        = errors.New("region not found")
+	ErrDuplicateRegion       = errors.New("region is declared more than once")
+	ErrDuplicateState        = errors.New("state is declared more than once")
+	ErrCrossRegionTransition = errors.New("transition cannot leave its region")
+	ErrInvalidJoin           = errors.New("join transition is invalid")
+	ErrRegionNotConfigured   = errors.New("region has no state machine")
+)
+
+// States returns the States of the StateConfiguration, followed by the States of each Region.
+func (c *Contract) States() []State {
+	states := c.State.States
+	for _, r := range c.State.Regions {
+		states = append(states[:len(states):len(states)], r.States...)
+	}
+	return states
+}
+
+// GetRegion returns the Region with the given name.
+func (c *Contract) GetRegion(name string) (Region, error) {
+	for _, r := range c.State.Regions {
+		if r.Name == name {
+			return r, nil
+		}
+	}
+	return Region{}, fmt.Errorf("%w: %s", ErrRegionNotFound, name)
+}
+
+// RegionOf returns the name of the Region declaring the State. The States of the StateConfiguration,
+// and undeclared States, belong to no Region.
+func (c *Contract) RegionOf(state string) string {
+	for _, r := range c.State.Regions {
+		for _, s := range r.States {
+			if s.Name == state {
+				return r.Name
+			}
+		}
+	}
+	return ""
+}
+
+// ValidateRegions validates that Regions and States are declared once, that the Initial State of each
+// Region is declared by it, that Transitions and superstates stay within their Region, and that join
+// Transitions are declared by the States of the StateConfiguration and reference declared Region States.
+func (c *Contract) ValidateRegions() error {
+	regions := make(map[string]bool)
+	for _, r := range c.State.Regions {
+		if regions[r.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateRegion, r.Name)
+		}
+		regions[r.Name] = true
+		if c.RegionOf(r.Initial) != r.Name {
+			return fmt.Errorf("%w: initial state %s (region %s)", ErrStateNotFound, r.Initial, r.Name)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range c.States() {
+		if seen[s.Name] {
+			return fmt.Errorf("%w: %s", ErrDuplicateState, s.Name)
+		}
+		seen[s.Name] = true
+	}
+
+	for _, s := range c.States() {
+		region := c.RegionOf(s.Name)
+		if s.Parent != "" && c.RegionOf(s.Parent) != region {
+			return fmt.Errorf("%w: %s (state %s)", ErrParentNotFound, s.Parent, s.Name)
+		}
+		for _, t := range s.Transitions {
+			if c.RegionOf(t.To) != region {
+				return fmt.Errorf("%w: %s (state %s, transition %s)", ErrCrossRegionTransition, t.To, s.Name, t.Name)
+			}
+			if len(t.Join) == 0 {
+				continue
+			}
+			if region != "" {
+				return fmt.Errorf("%w: %s (state %s): joins are declared outside of regions", ErrInvalidJoin, t.Name, s.Name)
+			}
+			for name, state := range t.Join {
+				if !regions[name] {
+					return fmt.Errorf("%w: %s (state %s, transition %s)", ErrRegionNotFound, name, s.Name, t.Name)
+				}
+				if c.RegionOf(state) != name {
+					return fmt.Errorf("%w: %s (state %s): state %s is not declared by region %s", ErrInvalidJoin, t.Name, s.Name, state, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// CurrentStates returns the current State of the SLC, followed by the current State of each Region.
+func (r *Reconciler) CurrentStates(ctx context.Context) ([]string, error) {
+	var states []string
+	for _, region := range r.regions() {
+		s, err := r.stateOf(ctx, region)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, s.Name)
+	}
+	return states, nil
+}
+
+// regions returns the names of the Regions of the SLC, preceded by "" for the StateConfiguration.
+func (r *Reconciler) regions() []string {
+	regions := []string{""}
+	for _, region := range r.Contract.State.Regions {
+		regions = append(regions, region.Name)
+	}
+	return regions
+}
+
+// machine returns the FSM of a Region, or the FSM of the StateConfiguration for "".
+func (r *Reconciler) machine(region string) (*stateless.StateMachine, error) {
+	if region == "" {
+		return r.FSM, nil
+	}
+	sm, ok := r.Regions[region]
+	if !ok || sm == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRegionNotConfigured, region)
+	}
+	return sm, nil
+}
+
+// updateStatus records the current States of the SLC in the Contract Status.
+func (r *Reconciler) updateStatus(ctx context.Context) {
+	states, err := r.CurrentStates(ctx)
+	if err != nil {
+		r.Logger.Error("Error getting current states", "error", err)
+		return
+	}
+	r.Contract.Status.CurrentState = states
+}
+
+// joined determines if every Region of a join Transition is in the State it requires.
+func (r *Reconciler) joined(ctx context.Context, t Transition) bool {
+	for region, state := range t.Join {
+		sm, err := r.machine(region)
+		if err != nil {
+			return false
+		}
+		in, err := sm.IsInStateCtx(ctx, state)
+		if err != nil || !in {
+			return false
+		}
+	}
+	return true
+}
+
+// join emits and consumes the trigger event of the first join Transition of the current State whose
+// Regions have all reached their States.
+func (r *Reconciler) join(ctx context.Context) error {
+	transitions, err := r.eligibleTransitions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range transitions {
+		if len(t.Join) == 0 || !r.joined(ctx, t) {
+			continue
+		}
+		evt, err := r.Contract.CreateEvent(t.On, JoinEventSource)
+		if err != nil {
+			return err
+		}
+		if err = evt.SetData(cloudevents.ApplicationJSON, map[string]interface{}{"join": t.Join}); err != nil {
+			return err
+		}
+
+		if r.Config.PublishSubject != "" && r.Stream != nil {
+			payload, _ := evt.MarshalJSON()
+			if _, err = r.Stream.Publish(ctx, r.Config.PublishSubject, payload); err != nil {
+				r.Logger.Error("Error publishing join event", "error", err)
+			}
+		}
+
+		r.Logger.Info("Regions joined", "transition", t.Name, "event", t.On)
+		return r.consumeEvent(ctx, &evt, map[string][]Transition{"": {t}}, true)
+	}
+	return nil
+}
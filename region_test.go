@@ -0,0 +1,251 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/qmuntal/stateless"
+)
+
+func TestReconcilerRegions(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/regions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions := make(map[string]*stateless.StateMachine)
+	for _, region := range c.State.Regions {
+		if regions[region.Name], err = NewRegionStateMachine(ctx, region.Name, region.Initial, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithRegions(regions), WithReconcilerLogger(logger))
+
+	steps := []struct {
+		event  string
+		states []string
+	}{
+		{event: "com.decombine.signature.sign", states: []string{"Active", "Unpaid", "Pending"}},
+		// The join Transition is not triggered before the Regions have joined.
+		{event: "com.decombine.contract.settle", states: []string{"Active", "Unpaid", "Pending"}},
+		{event: "com.decombine.delivery.ship", states: []string{"Active", "Unpaid", "Shipped"}},
+		{event: "com.decombine.payment.receive", states: []string{"Active", "Paid", "Shipped"}},
+		// Delivery joins Payment, which triggers Settle.
+		{event: "com.decombine.delivery.confirm", states: []string{"Fulfilled", "Paid", "Delivered"}},
+	}
+
+	for _, step := range steps {
+		evt, err := c.CreateEvent(step.event, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+		states, err := r.CurrentStates(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !equalSlices(states, step.states) {
+			t.Fatalf("after %s: expected states %v, got %v", step.event, step.states, states)
+		}
+		if !equalSlices(c.Status.CurrentState, step.states) {
+			t.Fatalf("after %s: expected status %v, got %v", step.event, step.states, c.Status.CurrentState)
+		}
+	}
+
+	select {
+	case <-r.done:
+	default:
+		t.Fatal("expected reconciler to stop after completion")
+	}
+}
+
+func TestReconcilerRegionsDeniedJoin(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/regions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The join Transition is denied once the Regions have joined.
+	c.State.States[1].Transitions[0].Conditions = []Condition{{Name: "deny", Engine: "custom", Value: "false"}}
+
+	sm, err := NewStateMachine(ctx, "Draft", c, WithConditionEvaluator("custom", staticEvaluator(false)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions := make(map[string]*stateless.StateMachine)
+	for _, region := range c.State.Regions {
+		if regions[region.Name], err = NewRegionStateMachine(ctx, region.Name, region.Initial, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithRegions(regions), WithReconcilerLogger(logger))
+
+	for _, event := range []string{"com.decombine.signature.sign", "com.decombine.delivery.ship",
+		"com.decombine.payment.receive", "com.decombine.delivery.confirm", "com.decombine.contract.settle"} {
+		evt, err := c.CreateEvent(event, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	states, err := r.CurrentStates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"Active", "Paid", "Delivered"}; !equalSlices(states, expected) {
+		t.Fatalf("expected states %v, got %v", expected, states)
+	}
+	select {
+	case <-r.done:
+		t.Fatal("expected reconciler not to complete")
+	default:
+	}
+}
+
+func TestReconcilerRegionsReentrantJoin(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/regions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The join Transition re-enters its source State, where the Regions remain joined.
+	c.State.States[1].Transitions[0].To = "Active"
+
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	regions := make(map[string]*stateless.StateMachine)
+	for _, region := range c.State.Regions {
+		if regions[region.Name], err = NewRegionStateMachine(ctx, region.Name, region.Initial, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithRegions(regions), WithReconcilerLogger(logger))
+
+	for _, event := range []string{"com.decombine.signature.sign", "com.decombine.delivery.ship",
+		"com.decombine.payment.receive", "com.decombine.delivery.confirm"} {
+		evt, err := c.CreateEvent(event, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The join Transition fires once.
+	var joins int
+	for _, entry := range c.Status.Audit {
+		if equalSlices(entry.Details, []string{"transition Settle"}) {
+			joins++
+		}
+	}
+	if joins != 1 {
+		t.Fatalf("expected the join transition to fire once, got %d", joins)
+	}
+}
+
+func TestValidateRegions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Contract)
+		err    error
+	}{
+		{
+			name:   "Valid",
+			modify: func(c *Contract) {},
+		},
+		{
+			name: "Duplicate region",
+			modify: func(c *Contract) {
+				c.State.Regions = append(c.State.Regions, c.State.Regions[0])
+			},
+			err: ErrDuplicateRegion,
+		},
+		{
+			name: "Duplicate state",
+			modify: func(c *Contract) {
+				c.State.Regions[0].States[1].Name = "Shipped"
+				c.State.Regions[0].States[0].Transitions[0].To = "Shipped"
+			},
+			err: ErrDuplicateState,
+		},
+		{
+			name: "Undeclared initial state",
+			modify: func(c *Contract) {
+				c.State.Regions[0].Initial = "Pending"
+			},
+			err: ErrStateNotFound,
+		},
+		{
+			name: "Cross region transition",
+			modify: func(c *Contract) {
+				c.State.Regions[0].States[0].Transitions[0].To = "Delivered"
+			},
+			err: ErrCrossRegionTransition,
+		},
+		{
+			name: "Join undeclared region",
+			modify: func(c *Contract) {
+				c.State.States[1].Transitions[0].Join["Warranty"] = "Expired"
+			},
+			err: ErrRegionNotFound,
+		},
+		{
+			name: "Join state of another region",
+			modify: func(c *Contract) {
+				c.State.States[1].Transitions[0].Join["Payment"] = "Delivered"
+			},
+			err: ErrInvalidJoin,
+		},
+		{
+			name: "Join declared in region",
+			modify: func(c *Contract) {
+				c.State.Regions[1].States[1].Transitions[0].Join = map[string]string{"Payment": "Paid"}
+			},
+			err: ErrInvalidJoin,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := GetFSContract("./tests/regions_ok.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.modify(c)
+			if err = c.ValidateRegions(); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
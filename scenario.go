@@ -0,0 +1,209 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-yaml"
+)
+
+// Scenario is a declarative test of a Contract: a sequence of Events replayed from a starting State, with the
+// expected outcome of each Event. Scenarios are written in YAML or JSON, and run with RunScenarios.
+type Scenario struct {
+	// The Name of the Scenario. Defaults to the name of its file.
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Description of the Scenario
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// States are the starting States: the State of the StateConfiguration, followed by the State of each Region.
+	// Defaults to the Initial States.
+	States []string `json:"states,omitempty" yaml:"states,omitempty"`
+	// Variables are the starting values of the State Variables, by Variable name.
+	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+	// Steps are the Events replayed, in order.
+	Steps []ScenarioStep `json:"steps" yaml:"steps" validate:"required,gte=1,dive"`
+}
+
+// ScenarioStep is an Event of a Scenario and its expected outcome.
+type ScenarioStep struct {
+	// The Type of the Event. E.g., "com.decombine.order.approve"
+	Event string `json:"event" yaml:"event" validate:"required"`
+	// The ID of the Party acting on the Event, if any
+	Party string `json:"party,omitempty" yaml:"party,omitempty"`
+	// The JSON Data of the Event
+	Data map[string]interface{} `json:"data,omitempty" yaml:"data,omitempty"`
+	// Expect is the expected outcome of the Event.
+	Expect ScenarioExpectation `json:"expect" yaml:"expect"`
+}
+
+// ScenarioExpectation is the expected outcome of a ScenarioStep. Only the outcomes declared are verified.
+type ScenarioExpectation struct {
+	// States are the current States after the Event.
+	States []string `json:"states,omitempty" yaml:"states,omitempty"`
+	// Transitions maps the names of the Transitions triggered by the Event to whether they fired.
+	Transitions map[string]bool `json:"transitions,omitempty" yaml:"transitions,omitempty"`
+	// Guards maps the names of the guard Conditions evaluated for the Event to whether they were satisfied.
+	Guards map[string]bool `json:"guards,omitempty" yaml:"guards,omitempty"`
+	// Variables are values of State Variables after the Event, by Variable name.
+	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty"`
+	// Completed indicates whether the Contract is completed after the Event.
+	Completed *bool `json:"completed,omitempty" yaml:"completed,omitempty"`
+}
+
+// GetFSScenario retrieves a Scenario from the file system, in JSON or YAML.
+func GetFSScenario(path string) (*Scenario, error) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s Scenario
+	switch getFileType(path) {
+	case JSON:
+		if err = json.Unmarshal(input, &s); err != nil {
+			return nil, ErrCannotUnmarshalJSON
+		}
+	case YAML:
+		if err = yaml.Unmarshal(input, &s); err != nil {
+			return nil, ErrCannotUnmarshalYAML
+		}
+	default:
+		return nil, errors.New("unknown or unsupported file format")
+	}
+	if s.Name == "" {
+		s.Name = filepath.Base(path)
+	}
+	return &s, validator.New(validator.WithRequiredStructEnabled()).Struct(s)
+}
+
+// Run simulates the Scenario against a Contract and returns a description of each expectation that is not
+// met. See Simulate for the FSMOptions.
+func (s *Scenario) Run(ctx context.Context, c *Contract, opts ...FSMOption) ([]string, error) {
+	contract := *c
+	contract.Status.CurrentState = s.States
+	contract.Status.Variables = s.Variables
+
+	events := make([]cloudevents.Event, len(s.Steps))
+	for i, step := range s.Steps {
+		evt, err := contract.CreateEvent(step.Event, "decombine/scenario")
+		if err != nil {
+			return nil, err
+		}
+		if step.Party != "" {
+			evt.SetExtension(PartyExtension, step.Party)
+		}
+		if err = evt.SetData(cloudevents.ApplicationJSON, step.Data); err != nil {
+			return nil, err
+		}
+		events[i] = evt
+	}
+
+	trace, err := Simulate(ctx, &contract, events, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []string
+	for i, step := range s.Steps {
+		result := trace.Steps[i]
+		fail := func(format string, args ...interface{}) {
+			failures = append(failures, fmt.Sprintf("step %d (%s): %s", i+1, step.Event, fmt.Sprintf(format, args...)))
+		}
+
+		// A Transition fired if it fired in any Region, and a Condition is satisfied if its last
+		// evaluation for the Event was.
+		fired := make(map[string]bool)
+		guards := make(map[string]bool)
+		for _, t := range result.Transitions {
+			fired[t.Name] = fired[t.Name] || t.Fired
+			for _, d := range t.Decisions {
+				guards[d.Condition] = d.Allowed
+			}
+		}
+
+		expect := step.Expect
+		if expect.States != nil && !slices.Equal(expect.States, result.States) {
+			fail("expected states %v, got %v", expect.States, result.States)
+		}
+		for _, name := range slices.Sorted(maps.Keys(expect.Transitions)) {
+			if got, ok := fired[name]; !ok {
+				fail("expected transition %s to be triggered", name)
+			} else if got != expect.Transitions[name] {
+				fail("expected transition %s to fire: %t, got %t", name, expect.Transitions[name], got)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(expect.Guards)) {
+			if got, ok := guards[name]; !ok {
+				fail("expected condition %s to be evaluated", name)
+			} else if got != expect.Guards[name] {
+				fail("expected condition %s to be satisfied: %t, got %t", name, expect.Guards[name], got)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(expect.Variables)) {
+			if got := result.Variables[name]; got != expect.Variables[name] {
+				fail("expected variable %s to be %q, got %q", name, expect.Variables[name], got)
+			}
+		}
+		if expect.Completed != nil && *expect.Completed != result.Completed {
+			fail("expected completed: %t, got %t", *expect.Completed, result.Completed)
+		}
+	}
+	return failures, nil
+}
+
+// RunScenarios runs the Scenarios of a directory against a Contract as subtests, one per Scenario file, e.g.,
+// from the tests of a Contract repository:
+//
+//	func TestContract(t *testing.T) {
+//		c, err := slc.GetFSContract("./contract.yaml")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		slc.RunScenarios(t, c, "./scenarios", slc.WithFSPolicyFiles("./policies"))
+//	}
+//
+// Scenario files are the JSON and YAML files of the directory. Each expectation that is not met fails the subtest.
+func RunScenarios(t *testing.T, c *Contract, dir string, opts ...FSMOption) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read scenarios: %v", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		switch path := filepath.Join(dir, e.Name()); getFileType(path) {
+		case JSON, YAML:
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		t.Fatalf("no scenarios found in %s", dir)
+	}
+
+	for _, path := range paths {
+		s, err := GetFSScenario(path)
+		if err != nil {
+			t.Errorf("invalid scenario %s: %v", path, err)
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			failures, err := s.Run(context.Background(), c, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range failures {
+				t.Error(f)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package slc
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRunScenarios(t *testing.T) {
+	c, err := GetFSContract("./tests/conditions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunScenarios(t, c, "./tests/scenarios/conditions")
+}
+
+func TestScenarioFailures(t *testing.T) {
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := false
+	s := Scenario{
+		Steps: []ScenarioStep{
+			{
+				Event: "com.decombine.order.approve",
+				Data:  map[string]interface{}{"order": map[string]interface{}{"amount": 500}},
+				Expect: ScenarioExpectation{
+					States:      []string{"Approved"},
+					Transitions: map[string]bool{"Approval": true},
+					Guards:      map[string]bool{"large.order": false, "small.order": true},
+					Variables:   map[string]string{"amount": "500"},
+					Completed:   &completed,
+				},
+			},
+		},
+	}
+
+	failures, err := s.Run(context.Background(), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"step 1 (com.decombine.order.approve): expected states [Approved], got [Draft]",
+		"step 1 (com.decombine.order.approve): expected transition Approval to fire: true, got false",
+		"step 1 (com.decombine.order.approve): expected condition small.order to be evaluated",
+		// Variables are only saved once a Transition fires.
+		`step 1 (com.decombine.order.approve): expected variable amount to be "500", got ""`,
+	}
+	if !equalSlices(failures, expected) {
+		t.Fatalf("expected %v, got %v", expected, failures)
+	}
+}
@@ -0,0 +1,220 @@
+package slc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// RejectedEventType is the type of the event published when the data of an Event does not conform to the
+// Schema of the Transition it triggers.
+const RejectedEventType = "com.decombine.slc.rejected"
+
+var (
+	ErrInvalidSchema   = errors.New("event schema is invalid")
+	ErrSchemaViolation = errors.New("event data does not conform to schema")
+)
+
+// EventSchema is the JSON Schema (https://json-schema.org) the data of the Event triggering a Transition
+// must conform to, declared either as a reference or inline.
+type EventSchema struct {
+	// Ref is the URL of a JSON Schema document. HTTP(S) URLs are fetched, other URLs are read from the
+	// file system. E.g., "https://schemas.decombine.com/signature.sign.json", "schemas/signature.sign.json"
+	Ref string `json:"ref,omitempty" yaml:"ref,omitempty" toml:"ref,omitempty"`
+	// Inline is a JSON Schema document declared in the Contract.
+	// E.g., {"type": "object", "required": ["signature"]}
+	Inline map[string]interface{} `json:"inline,omitempty" yaml:"inline,omitempty" toml:"inline,omitempty"`
+}
+
+// Rejection is the data of the event published when an Event is rejected.
+type Rejection struct {
+	// The ID of the rejected Event
+	EventID string `json:"eventId"`
+	// The type of the rejected Event
+	EventType string `json:"eventType"`
+	// The Transition the Event would have triggered
+	Transition string `json:"transition"`
+	// The Reason the Event was rejected
+	Reason string `json:"reason"`
+	// The Violations of the Schema by the event data, each prefixed by its location. E.g., "$.amount: must be > 0 but found 0"
+	Violations []string `json:"violations,omitempty"`
+}
+
+// inlineSchemaURL is the URL inline schemas are compiled at. Relative references of inline schemas are
+// resolved against the working directory, like the Ref of an EventSchema.
+const inlineSchemaURL = "inline.schema.json"
+
+// compile compiles the JSON Schema of the EventSchema, with JSON Schema draft 2020-12 as the default draft and
+// formats asserted. HTTP(S) references are fetched, other references are read from the file system.
+func (e *EventSchema) compile(ctx context.Context) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(location string) (io.ReadCloser, error) {
+		if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+			data, err := fetchDocument(ctx, location)
+			if err != nil {
+				return nil, err
+			}
+			return io.NopCloser(bytes.NewReader(data)), nil
+		}
+		return jsonschema.LoadURL(location)
+	}
+
+	location := e.Ref
+	if location == "" {
+		// Inline schemas are encoded as JSON so that they hold the same types as the event data.
+		document, err := json.Marshal(e.Inline)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+		location = inlineSchemaURL
+		if err = compiler.AddResource(location, bytes.NewReader(document)); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+		}
+	}
+	schema, err := compiler.Compile(location)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+	return schema, nil
+}
+
+// schemaViolations validates data against a compiled schema, returning the violations found, each prefixed by
+// the location of the violating value.
+func schemaViolations(schema *jsonschema.Schema, data interface{}) []string {
+	err := schema.Validate(data)
+	if err == nil {
+		return nil
+	}
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []string{"$: " + err.Error()}
+	}
+	var violations []string
+	var collect func(ve *jsonschema.ValidationError)
+	collect = func(ve *jsonschema.ValidationError) {
+		if len(ve.Causes) == 0 {
+			violations = append(violations, instancePath(ve.InstanceLocation)+": "+ve.Message)
+		}
+		for _, cause := range ve.Causes {
+			collect(cause)
+		}
+	}
+	collect(ve)
+	slices.Sort(violations)
+	return slices.Compact(violations)
+}
+
+// instancePath converts the JSON Pointer of a value within event data to a path. E.g., "/items/0/sku" is
+// "$.items[0].sku"
+func instancePath(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+	for _, token := range strings.Split(pointer, "/")[1:] {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if _, err := strconv.Atoi(token); err == nil {
+			path += "[" + token + "]"
+			continue
+		}
+		path += "." + token
+	}
+	return path
+}
+
+// ValidateSchemas validates that the Schema of every Transition declares either a reference or an inline
+// JSON Schema, and that inline schemas are well-formed.
+func (c *Contract) ValidateSchemas() error {
+	for _, s := range c.States() {
+		for _, t := range s.Transitions {
+			if t.Schema == nil {
+				continue
+			}
+			if (t.Schema.Ref == "") == (t.Schema.Inline == nil) {
+				return fmt.Errorf("%w: either ref or inline is required (state %s, transition %s)", ErrInvalidSchema, s.Name, t.Name)
+			}
+			if t.Schema.Inline == nil {
+				continue
+			}
+			if _, err := t.Schema.compile(context.Background()); err != nil {
+				return fmt.Errorf("%w (state %s, transition %s)", err, s.Name, t.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// schema returns the compiled Schema of a Transition. Referenced schemas are retrieved once.
+func (r *Reconciler) schema(ctx context.Context, e *EventSchema) (*jsonschema.Schema, error) {
+	if e.Ref != "" {
+		if s, ok := r.schemas.Load(e.Ref); ok {
+			return s.(*jsonschema.Schema), nil
+		}
+	}
+	s, err := e.compile(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if e.Ref != "" {
+		r.schemas.Store(e.Ref, s)
+	}
+	return s, nil
+}
+
+// validateSchema validates the data of an Event against the Schema of the Transition it triggers, before
+// any guard Condition is evaluated. Events that do not conform are rejected.
+func (r *Reconciler) validateSchema(ctx context.Context, event *cloudevents.Event, t Transition) error {
+	if t.Schema == nil {
+		return nil
+	}
+	s, err := r.schema(ctx, t.Schema)
+	if err != nil {
+		r.reject(ctx, event, t, err, nil)
+		return err
+	}
+	if violations := schemaViolations(s, eventInput(event)); len(violations) > 0 {
+		err = fmt.Errorf("%w: %s", ErrSchemaViolation, strings.Join(violations, "; "))
+		r.reject(ctx, event, t, ErrSchemaViolation, violations)
+		return err
+	}
+	return nil
+}
+
+// reject publishes a RejectedEventType event describing why an Event was rejected.
+func (r *Reconciler) reject(ctx context.Context, event *cloudevents.Event, t Transition, reason error, violations []string) {
+	evt, err := r.Contract.CreateEvent(RejectedEventType, "decombine")
+	if err != nil {
+		r.Logger.Error("Error creating rejected event", "error", err)
+		return
+	}
+	rejection := Rejection{
+		EventID:    event.ID(),
+		EventType:  event.Type(),
+		Transition: t.Name,
+		Reason:     reason.Error(),
+		Violations: violations,
+	}
+	if err = evt.SetData("application/json", rejection); err != nil {
+		r.Logger.Error("Error creating rejected event", "error", err)
+		return
+	}
+	if r.Config.PublishSubject == "" || r.Stream == nil {
+		r.Logger.Info("No publish subject configured. Skipping publishing rejected event.", "event", event.ID())
+		return
+	}
+	payload, _ := evt.MarshalJSON()
+	if _, err = r.Stream.Publish(ctx, r.Config.PublishSubject, payload); err != nil {
+		r.Logger.Error("Error publishing rejected event", "error", err)
+	}
+}
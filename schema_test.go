@@ -0,0 +1,225 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// publishedStream records the messages published to a JetStream.
+type publishedStream struct {
+	jetstream.JetStream
+	published [][]byte
+}
+
+func (s *publishedStream) Publish(_ context.Context, _ string, payload []byte, _ ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	s.published = append(s.published, payload)
+	return &jetstream.PubAck{}, nil
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["id", "amount"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"amount": {"type": "number", "exclusiveMinimum": 0, "multipleOf": 0.01},
+			"currency": {"enum": ["USD", "EUR"]},
+			"items": {"type": "array", "minItems": 1, "uniqueItems": true, "items": {"$ref": "#/$defs/item"}},
+			"payer": {"oneOf": [{"type": "string"}, {"type": "object", "required": ["id"]}]}
+		},
+		"additionalProperties": false,
+		"$defs": {
+			"item": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string", "pattern": "^[A-Z]-[0-9]+$"}}}
+		}
+	}`
+	var inline map[string]interface{}
+	if err := json.Unmarshal([]byte(schema), &inline); err != nil {
+		t.Fatal(err)
+	}
+	s, err := (&EventSchema{Inline: inline}).compile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		data       string
+		violations []string
+	}{
+		{name: "Conforming", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 10.5, "currency": "USD", "items": [{"sku": "A-1"}], "payer": "buyer"}`},
+		{name: "Not an object", data: `"payment"`, violations: []string{"$: expected object, but got string"}},
+		{name: "Required", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f"}`, violations: []string{"$: missing properties: 'amount'"}},
+		{name: "Format", data: `{"id": "42", "amount": 1}`, violations: []string{"$.id: '42' is not valid 'uuid'"}},
+		{name: "Exclusive minimum", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 0}`, violations: []string{"$.amount: must be > 0 but found 0"}},
+		{name: "Enum", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 1, "currency": "GBP"}`, violations: []string{`$.currency: value must be one of "USD", "EUR"`}},
+		{name: "Additional property", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 1, "note": "x"}`, violations: []string{"$: additionalProperties 'note' not allowed"}},
+		{name: "Reference", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 1, "items": [{"sku": "a1"}]}`, violations: []string{"$.items[0].sku: does not match pattern '^[A-Z]-[0-9]+$'"}},
+		{name: "Unique items", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 1, "items": [{"sku": "A-1"}, {"sku": "A-1"}]}`, violations: []string{"$.items: items at index 0 and 1 are equal"}},
+		{name: "One of", data: `{"id": "8c7a1f5e-8d1a-4b9e-9f3c-2a1b0c9d8e7f", "amount": 1, "payer": 42}`, violations: []string{"$.payer: expected object, but got number", "$.payer: expected string, but got number"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var data interface{}
+			if err := json.Unmarshal([]byte(test.data), &data); err != nil {
+				t.Fatal(err)
+			}
+			if violations := schemaViolations(s, data); !equalSlices(violations, test.violations) {
+				t.Fatalf("expected %v, got %v", test.violations, violations)
+			}
+		})
+	}
+}
+
+func TestValidateSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema *EventSchema
+		err    error
+	}{
+		{name: "No schema"},
+		{name: "Inline", schema: &EventSchema{Inline: map[string]interface{}{"type": "object"}}},
+		{name: "Ref", schema: &EventSchema{Ref: "tests/schemas/approval.json"}},
+		{name: "Empty", schema: &EventSchema{}, err: ErrInvalidSchema},
+		{name: "Ref and inline", schema: &EventSchema{Ref: "tests/schemas/approval.json", Inline: map[string]interface{}{}}, err: ErrInvalidSchema},
+		{name: "Invalid pattern", schema: &EventSchema{Inline: map[string]interface{}{"pattern": "["}}, err: ErrInvalidSchema},
+		{name: "Unresolved reference", schema: &EventSchema{Inline: map[string]interface{}{"$ref": "#/$defs/party"}}, err: ErrInvalidSchema},
+		{name: "Invalid subschema", schema: &EventSchema{Inline: map[string]interface{}{"items": "string"}}, err: ErrInvalidSchema},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := &Contract{State: StateConfiguration{Initial: "Draft", States: []State{
+				{Name: "Draft", Transitions: []Transition{{Name: "Signature", To: "Draft", On: "com.decombine.signature.sign", Schema: test.schema}}},
+			}}}
+			if err := c.ValidateSchemas(); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestReconcilerSchemas(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/schemas_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &publishedStream{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, stream, ReconcilerConfig{PublishSubject: "contracts.events"}, WithReconcilerLogger(logger))
+
+	consume := func(eventType, data string) string {
+		evt, err := c.CreateEvent(eventType, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = evt.SetData("application/json", []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+		return evt.ID()
+	}
+	rejection := func() Rejection {
+		t.Helper()
+		if len(stream.published) == 0 {
+			t.Fatal("expected a rejected event")
+		}
+		var evt struct {
+			Type string    `json:"type"`
+			Data Rejection `json:"data"`
+		}
+		if err := json.Unmarshal(stream.published[len(stream.published)-1], &evt); err != nil {
+			t.Fatal(err)
+		}
+		if evt.Type != RejectedEventType {
+			t.Fatalf("expected event type %s, got %s", RejectedEventType, evt.Type)
+		}
+		return evt.Data
+	}
+
+	// Data that does not conform to the inline schema is rejected before the guard is evaluated.
+	id := consume("com.decombine.signature.sign", `{"party": "buyer", "signature": "not a signature!"}`)
+	if state, _ := sm.State(ctx); state != "Draft" {
+		t.Fatalf("expected state Draft, got %v", state)
+	}
+	got := rejection()
+	if got.EventID != id || got.Transition != "Signature" || !equalSlices(got.Violations, []string{"$.signature: does not match pattern '^[A-Za-z0-9_-]+$'"}) {
+		t.Fatalf("unexpected rejection: %+v", got)
+	}
+
+	consume("com.decombine.signature.sign", `{"party": "buyer", "signature": "c2lnbmF0dXJl"}`)
+	if state, _ := sm.State(ctx); state != "Signed" {
+		t.Fatalf("expected state Signed, got %v", state)
+	}
+
+	// Referenced schemas are read from the file system.
+	published := len(stream.published)
+	consume("com.decombine.order.approve", `{"approver": {"id": ""}, "approvedAt": "today"}`)
+	if state, _ := sm.State(ctx); state != "Signed" {
+		t.Fatalf("expected state Signed, got %v", state)
+	}
+	if len(stream.published) != published+1 {
+		t.Fatal("expected a rejected event")
+	}
+	expected := []string{"$.approvedAt: 'today' is not valid 'date-time'", "$.approver.id: length must be >= 1, but got 0"}
+	if got = rejection(); !equalSlices(got.Violations, expected) {
+		t.Fatalf("expected %v, got %v", expected, got.Violations)
+	}
+
+	consume("com.decombine.order.approve", `{"approver": {"id": "seller"}, "approvedAt": "2025-06-01T12:00:00Z"}`)
+	if state, _ := sm.State(ctx); state != "Approved" {
+		t.Fatalf("expected state Approved, got %v", state)
+	}
+}
+
+func TestReconcilerSchemasUnauthenticated(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/schemas_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := &publishedStream{}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, stream, ReconcilerConfig{PublishSubject: "contracts.events"}, WithReconcilerLogger(logger),
+		WithEventAuthenticators(NewPartySignatureAuthenticator(c)))
+
+	// Unauthenticated events are rejected before their data is validated, without publishing a rejected event.
+	evt, err := c.CreateEvent("com.decombine.signature.sign", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = evt.SetData("application/json", []byte(`{"signature": "not a signature!"}`)); err != nil {
+		t.Fatal(err)
+	}
+	eligible, err := r.eligibleTransitions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+		t.Fatal(err)
+	}
+	if len(stream.published) != 0 {
+		t.Fatalf("expected no rejected event, got %d", len(stream.published))
+	}
+}
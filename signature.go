@@ -0,0 +1,332 @@
+package slc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+)
+
+const (
+	// DigestAlgorithm is the prefix of a Contract content digest.
+	DigestAlgorithm = "sha256"
+)
+
+var (
+	ErrContractUnsigned      = errors.New("contract is not signed")
+	ErrContractTampered      = errors.New("contract digest does not match signed digest")
+	ErrSignatureInvalid      = errors.New("contract signature is invalid")
+	ErrUnsupportedSigningKey = errors.New("unsupported signing key")
+	ErrSignerNotParty        = errors.New("signer is not a party to the contract")
+	ErrPartyKeyNotPinned     = errors.New("party does not declare a public key")
+	ErrPartyNotSigned        = errors.New("party has not signed the contract")
+)
+
+// supportedSignatureAlgorithms are the JWS algorithms accepted when verifying Contract Signatures.
+var supportedSignatureAlgorithms = []jose.SignatureAlgorithm{jose.EdDSA, jose.ES256, jose.ES384, jose.ES512}
+
+// A Signature is a detached signature from a party over the content digest of a Contract.
+type Signature struct {
+	// Party is the identifier of the signing party.
+	Party string `json:"party" yaml:"party" toml:"party" validate:"required"`
+	// Algorithm is the JWS algorithm used to sign. E.g., "EdDSA", "ES256"
+	Algorithm string `json:"algorithm" yaml:"algorithm" toml:"algorithm" validate:"required"`
+	// PublicKey is the PEM encoded public key used to verify the Signature.
+	PublicKey string `json:"publicKey" yaml:"publicKey" toml:"publicKey" validate:"required"`
+	// Digest is the Contract content digest that was signed. E.g., "sha256:9f86d0..."
+	Digest string `json:"digest" yaml:"digest" toml:"digest" validate:"required"`
+	// Value is the JWS compact serialization of the Signature with a detached payload.
+	Value string `json:"value" yaml:"value" toml:"value" validate:"required"`
+	// SignedAt is the RFC3339 timestamp of when the Signature was created.
+	SignedAt string `json:"signedAt,omitempty" yaml:"signedAt,omitempty" toml:"signedAt,omitempty"`
+}
+
+// DigestOptions holds the external content included in a Contract content digest.
+type DigestOptions struct {
+	// Policies maps a Condition Path to the content of the policy file.
+	Policies map[string][]byte
+	// Text is the content of the contract text.
+	Text []byte
+}
+
+type DigestOption func(*DigestOptions)
+
+// WithPolicyContent is a DigestOption that includes the content of a policy file in the digest.
+// The path should match the Condition Path referencing the policy.
+func WithPolicyContent(path string, content []byte) DigestOption {
+	return func(opts *DigestOptions) {
+		if opts.Policies == nil {
+			opts.Policies = make(map[string][]byte)
+		}
+		opts.Policies[path] = content
+	}
+}
+
+// WithTextContent is a DigestOption that includes the content of the contract text in the digest.
+func WithTextContent(text []byte) DigestOption {
+	return func(opts *DigestOptions) {
+		opts.Text = text
+	}
+}
+
+// digestDocument is the canonical document hashed to produce a Contract content digest.
+type digestDocument struct {
+	Definition json.RawMessage   `json:"definition"`
+	Policies   map[string]string `json:"policies,omitempty"`
+	Text       string            `json:"text,omitempty"`
+}
+
+// Digest returns the canonical content digest of the Contract definition, and any policy or text
+// content provided as DigestOption. The Status and Signatures of the Contract are excluded so the
+// digest remains stable while the Contract is running and as parties sign.
+func (c *Contract) Digest(opts ...DigestOption) (string, error) {
+	options := &DigestOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	definition := *c
+	definition.Status = Status{}
+	definition.Signatures = nil
+
+	// encoding/json emits struct fields in declaration order and sorts map keys,
+	// which makes the marshalled definition canonical.
+	raw, err := json.Marshal(definition)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal contract definition: %w", err)
+	}
+
+	doc := digestDocument{Definition: raw}
+	if len(options.Policies) > 0 {
+		paths := make([]string, 0, len(options.Policies))
+		for p := range options.Policies {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		doc.Policies = make(map[string]string, len(paths))
+		for _, p := range paths {
+			doc.Policies[p] = sha256Hex(options.Policies[p])
+		}
+	}
+	if options.Text != nil {
+		doc.Text = sha256Hex(options.Text)
+	}
+
+	out, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal contract digest: %w", err)
+	}
+	return DigestAlgorithm + ":" + sha256Hex(out), nil
+}
+
+// DigestContent loads the policy files of the Conditions and the TextSources of the Contract as the FSM does with
+// the FSMOptions, and returns them as DigestOptions. The FSM verifies Signatures over this content, so Contracts
+// verified with WithSignatureVerification are signed with these DigestOptions.
+func (c *Contract) DigestContent(ctx context.Context, opts ...FSMOption) ([]DigestOption, error) {
+	options := &FSMOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	guard := &conditionGuard{contract: c, options: options}
+	content, _, err := guard.digestContent(ctx)
+	return content, err
+}
+
+// Sign creates a detached JWS Signature for the party over the Contract content digest and appends
+// it to the Contract Signatures. Supported keys are ed25519.PrivateKey and *ecdsa.PrivateKey.
+func (c *Contract) Sign(party string, key crypto.Signer, opts ...DigestOption) (*Signature, error) {
+	if party == "" {
+		return nil, errors.New("party cannot be empty")
+	}
+	alg, err := signingAlgorithm(key)
+	if err != nil {
+		return nil, err
+	}
+
+	digest, err := c.Digest(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, nil)
+	if err != nil {
+		return nil, err
+	}
+	jws, err := signer.Sign([]byte(digest))
+	if err != nil {
+		return nil, err
+	}
+	value, err := jws.DetachedCompactSerialize()
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, err := encodePublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	sig := Signature{
+		Party:     party,
+		Algorithm: string(alg),
+		PublicKey: publicKey,
+		Digest:    digest,
+		Value:     value,
+		SignedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	c.Signatures = append(c.Signatures, sig)
+	return &sig, nil
+}
+
+// Verify checks that the Contract has at least one Signature, that every Signature was made by a Party of the
+// Contract over the current content digest, and that every Signature is cryptographically valid under the
+// PublicKey pinned by its Party. The PublicKey embedded in a Signature is never trusted on its own: Parties
+// that do not declare a PublicKey cannot sign.
+func (c *Contract) Verify(opts ...DigestOption) error {
+	return c.verifySignatures(c, opts...)
+}
+
+// verifySignatures verifies the Signatures of the Contract like Verify, against the PublicKeys pinned by the
+// Parties of the trusted Contract. E.g., an amendment is verified against the Contract it amends.
+func (c *Contract) verifySignatures(trusted *Contract, opts ...DigestOption) error {
+	if len(c.Signatures) == 0 {
+		return ErrContractUnsigned
+	}
+
+	digest, err := c.Digest(opts...)
+	if err != nil {
+		return err
+	}
+
+	for _, sig := range c.Signatures {
+		if sig.Digest != digest {
+			return fmt.Errorf("%w: party %s", ErrContractTampered, sig.Party)
+		}
+		p, err := trusted.GetParty(sig.Party)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrSignerNotParty, sig.Party)
+		}
+		if p.PublicKey == "" {
+			return fmt.Errorf("%w: party %s", ErrPartyKeyNotPinned, sig.Party)
+		}
+		if !samePublicKey(p.PublicKey, sig.PublicKey) {
+			return fmt.Errorf("%w: party %s", ErrPartyKeyMismatch, sig.Party)
+		}
+		if err = sig.Verify(digest, p.PublicKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyAllParties verifies the Contract like Verify, and that every Party of the Contract has signed it.
+func (c *Contract) VerifyAllParties(opts ...DigestOption) error {
+	if err := c.Verify(opts...); err != nil {
+		return err
+	}
+	for _, p := range c.Parties {
+		if !slices.ContainsFunc(c.Signatures, func(sig Signature) bool { return sig.Party == p.ID }) {
+			return fmt.Errorf("%w: party %s", ErrPartyNotSigned, p.ID)
+		}
+	}
+	return nil
+}
+
+// Verify checks the Signature against the given content digest with a trusted PEM encoded public key, e.g.,
+// the PublicKey pinned by the signing Party.
+func (s Signature) Verify(digest, publicKey string) error {
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return fmt.Errorf("%w: party %s: %v", ErrSignatureInvalid, s.Party, err)
+	}
+	jws, err := jose.ParseDetached(s.Value, []byte(digest), supportedSignatureAlgorithms)
+	if err != nil {
+		return fmt.Errorf("%w: party %s: %v", ErrSignatureInvalid, s.Party, err)
+	}
+	if len(jws.Signatures) != 1 || jws.Signatures[0].Header.Algorithm != s.Algorithm {
+		return fmt.Errorf("%w: party %s: algorithm mismatch", ErrSignatureInvalid, s.Party)
+	}
+	if err = jws.DetachedVerify([]byte(digest), key); err != nil {
+		return fmt.Errorf("%w: party %s: %v", ErrSignatureInvalid, s.Party, err)
+	}
+	return nil
+}
+
+// signingAlgorithm returns the JWS algorithm for a supported signing key.
+func signingAlgorithm(key crypto.Signer) (jose.SignatureAlgorithm, error) {
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return jose.EdDSA, nil
+	case *ecdsa.PrivateKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return jose.ES256, nil
+		case elliptic.P384():
+			return jose.ES384, nil
+		case elliptic.P521():
+			return jose.ES512, nil
+		}
+	}
+	return "", ErrUnsupportedSigningKey
+}
+
+// encodePublicKey PEM encodes a public key, e.g., to pin it as the PublicKey of a Party.
+func encodePublicKey(key crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// parsePublicKey decodes a PEM encoded ed25519 or ECDSA public key.
+func parsePublicKey(in string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(in))
+	if block == nil {
+		return nil, errors.New("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return k, nil
+	}
+	return nil, errors.New("unsupported public key type")
+}
+
+// samePublicKey determines if two PEM encoded public keys are the same key.
+func samePublicKey(a, b string) bool {
+	ka, err := parsePublicKey(a)
+	if err != nil {
+		return false
+	}
+	kb, err := parsePublicKey(b)
+	if err != nil {
+		return false
+	}
+	type equaler interface {
+		Equal(crypto.PublicKey) bool
+	}
+	e, ok := ka.(equaler)
+	return ok && e.Equal(kb)
+}
+
+func sha256Hex(in []byte) string {
+	sum := sha256.Sum256(in)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,234 @@
+package slc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// pinnedParty returns a Party pinning the public key of the signing key.
+func pinnedParty(t *testing.T, id string, key crypto.Signer) Party {
+	t.Helper()
+	publicKey, err := encodePublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Party{ID: id, Role: id, PublicKey: publicKey}
+}
+
+func TestContractSignatures(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		keys     map[string]crypto.Signer
+		unpinned string
+		tamper   func(c *Contract)
+		err      error
+	}{
+		{
+			name: "Unsigned",
+			err:  ErrContractUnsigned,
+		},
+		{
+			name: "Signed by both parties",
+			keys: map[string]crypto.Signer{"buyer": edKey, "seller": ecKey},
+		},
+		{
+			name:   "Tampered definition",
+			keys:   map[string]crypto.Signer{"buyer": edKey},
+			tamper: func(c *Contract) { c.State.Initial = "In Process" },
+			err:    ErrContractTampered,
+		},
+		{
+			name: "Status changes are not tampering",
+			keys: map[string]crypto.Signer{"buyer": edKey},
+			tamper: func(c *Contract) {
+				c.Status.CurrentState = []string{"In Process"}
+			},
+		},
+		{
+			name: "Forged signature value",
+			keys: map[string]crypto.Signer{"buyer": edKey},
+			tamper: func(c *Contract) {
+				c.Signatures[0].Value = c.Signatures[0].Value[:len(c.Signatures[0].Value)-4] + "AAAA"
+			},
+			err: ErrSignatureInvalid,
+		},
+		{
+			name: "Signer is not a party",
+			keys: map[string]crypto.Signer{"auditor": edKey},
+			err:  ErrSignerNotParty,
+		},
+		{
+			name:     "Party key not pinned",
+			keys:     map[string]crypto.Signer{"buyer": edKey},
+			unpinned: "buyer",
+			err:      ErrPartyKeyNotPinned,
+		},
+		{
+			name: "Signed with another key",
+			keys: map[string]crypto.Signer{"buyer": ecKey},
+			err:  ErrPartyKeyMismatch,
+		},
+		{
+			name: "Embedded key replaced",
+			keys: map[string]crypto.Signer{"seller": ecKey},
+			tamper: func(c *Contract) {
+				// A Signature is verified with the pinned key, not the key it embeds.
+				other, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+				content, _ := c.DigestContent(context.Background(), WithFSPolicyFiles("./tests/policies"))
+				forged, _ := c.Sign("seller", other, append([]DigestOption{WithTextContent([]byte("agreement"))}, content...)...)
+				c.Signatures = []Signature{*forged}
+				c.Signatures[0].PublicKey = c.Parties[1].PublicKey
+			},
+			err: ErrSignatureInvalid,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := GetFSContract("./tests/minimal_ok.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.Parties = []Party{pinnedParty(t, "buyer", edKey), pinnedParty(t, "seller", ecKey)}
+			for i := range c.Parties {
+				if c.Parties[i].ID == test.unpinned {
+					c.Parties[i].PublicKey = ""
+				}
+			}
+			// The Contract is signed over the policy files loaded by the FSM.
+			content, err := c.DigestContent(context.Background(), WithFSPolicyFiles("./tests/policies"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			digest := append([]DigestOption{WithTextContent([]byte("agreement"))}, content...)
+			for party, key := range test.keys {
+				if _, err = c.Sign(party, key, digest...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.tamper != nil {
+				test.tamper(c)
+			}
+
+			err = c.Verify(digest...)
+			if test.err == nil && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if test.err != nil && !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+
+			_, err = NewStateMachine(context.Background(), "Draft", c, WithFSPolicyFiles("./tests/policies"), WithSignatureVerification(WithTextContent([]byte("agreement"))))
+			if test.err == nil && err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if test.err != nil && err == nil {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestContractSignaturesAllParties(t *testing.T) {
+	_, buyerKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, sellerKey, _ := ed25519.GenerateKey(rand.Reader)
+	c, err := GetFSContract("./tests/minimal_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Parties = []Party{pinnedParty(t, "buyer", buyerKey), pinnedParty(t, "seller", sellerKey)}
+	policies := WithFSPolicyFiles("./tests/policies")
+	digest, err := c.DigestContent(context.Background(), policies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Sign("buyer", buyerKey, digest...); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = c.Verify(digest...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err = c.VerifyAllParties(digest...); !errors.Is(err, ErrPartyNotSigned) {
+		t.Fatalf("expected %v, got %v", ErrPartyNotSigned, err)
+	}
+	if _, err = NewStateMachine(context.Background(), "Draft", c, policies, WithAllPartySignatures()); !errors.Is(err, ErrPartyNotSigned) {
+		t.Fatalf("expected %v, got %v", ErrPartyNotSigned, err)
+	}
+
+	if _, err = c.Sign("seller", sellerKey, digest...); err != nil {
+		t.Fatal(err)
+	}
+	if err = c.VerifyAllParties(digest...); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestContractDigestIncludesPolicies(t *testing.T) {
+	c, err := GetFSContract("./tests/minimal_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := c.Digest(WithPolicyContent("only.admin.rego", []byte("package only.admin")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.Digest(WithPolicyContent("only.admin.rego", []byte("package only.other")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a == b {
+		t.Fatal("expected policy content to change the digest")
+	}
+}
+
+func TestContractSignaturesPolicyContent(t *testing.T) {
+	ctx := context.Background()
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	c, err := GetFSContract("./tests/minimal_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Parties = []Party{pinnedParty(t, "buyer", key)}
+	digest, err := c.DigestContent(ctx, WithFSPolicyFiles("./tests/policies"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.Sign("buyer", key, digest...); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewStateMachine(ctx, "Draft", c, WithFSPolicyFiles("./tests/policies"), WithSignatureVerification()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// A swapped policy file no longer verifies, whatever policy content is passed as DigestOption.
+	dir := t.TempDir()
+	if err = os.WriteFile(filepath.Join(dir, "only.admin.rego"), []byte("package only.admin\n\nallow := true"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile("./tests/policies/only.admin.rego")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewStateMachine(ctx, "Draft", c, WithFSPolicyFiles(dir), WithSignatureVerification(WithPolicyContent("only.admin.rego", original)))
+	if !errors.Is(err, ErrContractTampered) {
+		t.Fatalf("expected %v, got %v", ErrContractTampered, err)
+	}
+}
@@ -0,0 +1,214 @@
+package slc
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/qmuntal/stateless"
+)
+
+// Kinds of SimulatedAction, by when the Action would run.
+const (
+	ActionKindExit       = "exit"
+	ActionKindTransition = "transition"
+	ActionKindEntry      = "entry"
+)
+
+// GuardDecision is the evaluation of a guard Condition of a Transition.
+type GuardDecision struct {
+	// The State declaring the Transition
+	State string `json:"state"`
+	// The Transition guarded by the Condition
+	Transition string `json:"transition"`
+	// The Condition evaluated, including its nested Conditions
+	Condition string `json:"condition"`
+	// Allowed indicates the Condition is satisfied.
+	Allowed bool `json:"allowed"`
+}
+
+// SimulationTrace is the trace of a sequence of Events replayed against a Contract. See Simulate.
+type SimulationTrace struct {
+	// Steps are the Events replayed, in order.
+	Steps []SimulationStep `json:"steps"`
+	// States are the current States after the last Event: the State of the StateConfiguration, followed by
+	// the State of each Region.
+	States []string `json:"states"`
+	// Variables are the values of the State Variables after the last Event.
+	Variables map[string]string `json:"variables,omitempty"`
+	// Completed indicates a Final State of the StateConfiguration was entered. Events after completion
+	// are ignored.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// SimulationStep is the outcome of an Event replayed against a Contract.
+type SimulationStep struct {
+	// The ID of the Event
+	EventID string `json:"eventId"`
+	// The Type of the Event
+	EventType string `json:"eventType"`
+	// Transitions triggered by the Event, or by Regions joining after the Event, in the order they were fired.
+	// Events rejected before any guard Condition is evaluated trigger no Transition.
+	Transitions []SimulatedTransition `json:"transitions,omitempty"`
+	// States are the current States after the Event.
+	States []string `json:"states"`
+	// Variables are the values of the State Variables after the Event.
+	Variables map[string]string `json:"variables,omitempty"`
+	// Completed indicates the Contract is completed after the Event.
+	Completed bool `json:"completed,omitempty"`
+}
+
+// SimulatedTransition is a Transition triggered in a Region, or in the StateConfiguration for "".
+type SimulatedTransition struct {
+	// The Region of the Transition, or "" for the StateConfiguration
+	Region string `json:"region,omitempty"`
+	// The Name of the Transition
+	Name string `json:"name"`
+	// The State the Region was in
+	From string `json:"from"`
+	// The State the Region is in after the Transition. Equal to From if the Transition was not fired.
+	To string `json:"to"`
+	// Fired indicates the guard Conditions were satisfied and the Transition occurred.
+	Fired bool `json:"fired"`
+	// Decisions are the guard Conditions evaluated to fire the Transition, including those of other
+	// Transitions triggered by the same Event.
+	Decisions []GuardDecision `json:"decisions,omitempty"`
+	// Exited are the States exited, from the source outwards.
+	Exited []string `json:"exited,omitempty"`
+	// Entered are the States entered, from the outermost superstate inwards.
+	Entered []string `json:"entered,omitempty"`
+	// Actions are the Actions that would run, in order. Actions are never executed by a simulation.
+	Actions []SimulatedAction `json:"actions,omitempty"`
+}
+
+// SimulatedAction is an Action that would run when a Transition occurs.
+type SimulatedAction struct {
+	// The Kind of Action, either "exit", "transition" or "entry"
+	Kind string `json:"kind"`
+	// The State exited or entered, or the Transition, running the Action
+	Source string `json:"source"`
+	Action Action `json:"action"`
+}
+
+// A transitionObserver observes the Transitions of a Reconciler, whether they fired or were denied, with the
+// States they exited and entered.
+type transitionObserver interface {
+	observeTransition(region string, t Transition, from, to string, fired bool, exited, entered []State)
+}
+
+// simulator records the SimulationSteps of a Reconciler during a simulation. It is the transitionObserver of
+// the Reconciler of a simulation.
+type simulator struct {
+	step      *SimulationStep
+	decisions []GuardDecision
+}
+
+func (s *simulator) decide(d GuardDecision) {
+	s.decisions = append(s.decisions, d)
+}
+
+// observeTransition records a Transition of the current SimulationStep with the guard decisions since the
+// last one.
+func (s *simulator) observeTransition(region string, t Transition, from, to string, fired bool, exited, entered []State) {
+	st := SimulatedTransition{Region: region, Name: t.Name, From: from, To: to, Fired: fired, Decisions: s.decisions}
+	s.decisions = nil
+	for _, e := range exited {
+		st.Exited = append(st.Exited, e.Name)
+		st.Actions = appendAction(st.Actions, ActionKindExit, e.Name, e.Exit)
+	}
+	if fired {
+		st.Actions = appendAction(st.Actions, ActionKindTransition, t.Name, t.Actions)
+	}
+	for _, e := range entered {
+		st.Entered = append(st.Entered, e.Name)
+		st.Actions = appendAction(st.Actions, ActionKindEntry, e.Name, e.Entry)
+	}
+	s.step.Transitions = append(s.step.Transitions, st)
+}
+
+func appendAction(actions []SimulatedAction, kind, source string, action Action) []SimulatedAction {
+	if action.ActionType == "" && len(action.KubernetesActions) == 0 {
+		return actions
+	}
+	return append(actions, SimulatedAction{Kind: kind, Source: source, Action: action})
+}
+
+// Simulate replays a sequence of Events against a Contract, offline, and returns the trace of the guard
+// decisions, States entered and Actions that would run. The Events are consumed as by a Reconciler without
+// Kubernetes client or Stream, so Actions are never executed and no Event is published. The simulation starts
+// in the current States of the Contract, or in its Initial States, and does not modify the Contract. Use
+// WithFSPolicyFiles to evaluate local policies. Timed Transitions are simulated by replaying their trigger Event.
+func Simulate(ctx context.Context, c *Contract, events []cloudevents.Event, opts ...FSMOption) (*SimulationTrace, error) {
+	options := &FSMOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	contract := *c
+	contract.Status.CurrentState = slices.Clone(c.Status.CurrentState)
+	contract.Status.Variables = maps.Clone(c.Status.Variables)
+	contract.Status.Audit = slices.Clip(c.Status.Audit)
+	current := contract.Status.CurrentState
+	if len(current) != len(c.State.Regions)+1 {
+		current = []string{c.State.Initial}
+		for _, region := range c.State.Regions {
+			current = append(current, region.Initial)
+		}
+	}
+
+	sim := &simulator{}
+	opts = append(slices.Clone(opts), withGuardDecisions(sim.decide))
+	fsm, err := NewStateMachine(ctx, current[0], &contract, opts...)
+	if err != nil {
+		return nil, err
+	}
+	regions := make(map[string]*stateless.StateMachine)
+	for i, region := range contract.State.Regions {
+		if regions[region.Name], err = NewRegionStateMachine(ctx, region.Name, current[i+1], &contract, opts...); err != nil {
+			return nil, err
+		}
+	}
+
+	// Simulated events are trusted to act on behalf of the Party they name.
+	r := NewReconciler(&contract, fsm, nil, nil, ReconcilerConfig{}, WithRegions(regions), WithReconcilerLogger(logger),
+		WithUnauthenticatedParties())
+	r.observer = sim
+	if len(contract.Models) > 0 {
+		if r.Models, err = LoadConcertoModels(ctx, contract.Models, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	trace := &SimulationTrace{}
+	for _, event := range events {
+		sim.step = &SimulationStep{EventID: event.ID(), EventType: event.Type()}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if err = r.ConsumeEvent(ctx, &event, eligible); err != nil {
+			return nil, err
+		}
+		sim.decisions = nil
+		if sim.step.States, err = r.CurrentStates(ctx); err != nil {
+			return nil, err
+		}
+		sim.step.Variables = maps.Clone(contract.Status.Variables)
+		sim.step.Completed = r.completed()
+		trace.Steps = append(trace.Steps, *sim.step)
+	}
+
+	if trace.States, err = r.CurrentStates(ctx); err != nil {
+		return nil, err
+	}
+	trace.Variables = contract.Status.Variables
+	trace.Completed = r.completed()
+	return trace, nil
+}
@@ -0,0 +1,132 @@
+package slc
+
+import (
+	"context"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// simulationEvent creates an Event of the Contract acted on by a Party, with JSON data.
+func simulationEvent(t *testing.T, c *Contract, eventType, party string, data map[string]interface{}) cloudevents.Event {
+	t.Helper()
+	evt, err := c.CreateEvent(eventType, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if party != "" {
+		evt.SetExtension(PartyExtension, party)
+	}
+	if err = evt.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		t.Fatal(err)
+	}
+	return evt
+}
+
+func TestSimulate(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/conditions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []cloudevents.Event{
+		simulationEvent(t, c, "com.decombine.order.approve", "acme", map[string]interface{}{"amount": 5000}),
+		simulationEvent(t, c, "com.decombine.order.approve", "acme", map[string]interface{}{"amount": 500, "flagged": false}),
+		// Events after completion are ignored.
+		simulationEvent(t, c, "com.decombine.order.approve", "globex", map[string]interface{}{}),
+	}
+	trace, err := Simulate(ctx, c, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(trace.Steps) != 3 || !trace.Completed || !equalSlices(trace.States, []string{"Approved"}) {
+		t.Fatalf("unexpected trace %+v", trace)
+	}
+	expected := []struct {
+		fired   bool
+		allowed bool
+		states  []string
+	}{
+		{fired: false, allowed: false, states: []string{"Draft"}},
+		{fired: true, allowed: true, states: []string{"Approved"}},
+	}
+	for i, e := range expected {
+		step := trace.Steps[i]
+		if step.EventID != events[i].ID() || !equalSlices(step.States, e.states) || len(step.Transitions) != 1 {
+			t.Fatalf("step %d: unexpected step %+v", i, step)
+		}
+		tr := step.Transitions[0]
+		if tr.Name != "Approval" || tr.Fired != e.fired || len(tr.Decisions) != 1 {
+			t.Fatalf("step %d: unexpected transition %+v", i, tr)
+		}
+		d := tr.Decisions[0]
+		if d.Condition != "buyer.or.small.unflagged.order" || d.Transition != "Approval" || d.Allowed != e.allowed {
+			t.Fatalf("step %d: unexpected decision %+v", i, d)
+		}
+	}
+	if len(trace.Steps[2].Transitions) != 0 {
+		t.Fatalf("expected no transition after completion, got %+v", trace.Steps[2].Transitions)
+	}
+
+	// The Contract is not modified by the simulation.
+	if len(c.Status.CurrentState) != 0 {
+		t.Fatalf("unexpected status %+v", c.Status)
+	}
+}
+
+func TestSimulateActions(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := []cloudevents.Event{
+		simulationEvent(t, c, "com.decombine.order.approve", "", map[string]interface{}{"order": map[string]interface{}{"amount": 5000}}),
+	}
+	trace, err := Simulate(ctx, c, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trace.Variables["amount"] != "5000" || c.Status.Variables != nil {
+		t.Fatalf("unexpected variables %v, %v", trace.Variables, c.Status.Variables)
+	}
+
+	tr := trace.Steps[0].Transitions[0]
+	if !tr.Fired || !equalSlices(tr.Exited, []string{"Draft"}) || !equalSlices(tr.Entered, []string{"Approved"}) {
+		t.Fatalf("unexpected transition %+v", tr)
+	}
+	// The Entry action of Approved would run, but is not executed.
+	if len(tr.Actions) != 1 || tr.Actions[0].Kind != ActionKindEntry || tr.Actions[0].Source != "Approved" ||
+		tr.Actions[0].Action.KubernetesActions[0].Name != "approved" {
+		t.Fatalf("unexpected actions %+v", tr.Actions)
+	}
+}
+
+func TestSimulateRegions(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/regions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Status.CurrentState = []string{"Active", "Paid", "Shipped"}
+
+	events := []cloudevents.Event{
+		simulationEvent(t, c, "com.decombine.delivery.confirm", "", map[string]interface{}{}),
+	}
+	trace, err := Simulate(ctx, c, events)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Delivery joins Payment, which triggers Settle in the same step.
+	step := trace.Steps[0]
+	if len(step.Transitions) != 2 || step.Transitions[0].Region != "Delivery" || step.Transitions[1].Name != "Settle" {
+		t.Fatalf("unexpected transitions %+v", step.Transitions)
+	}
+	if !equalSlices(step.States, []string{"Fulfilled", "Paid", "Delivered"}) || !trace.Completed {
+		t.Fatalf("unexpected trace %+v", trace)
+	}
+}
@@ -1,9 +1,12 @@
 package slc
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 
 	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"github.com/goccy/go-yaml"
 )
 
 const (
@@ -24,12 +27,18 @@ type Contract struct {
 	Source GitSource `json:"source" yaml:"source" toml:"source"`
 	// The Policy included in the SLC
 	Policy PolicySource `json:"policy" yaml:"policy" toml:"policy"`
+	// The Concerto Models declaring the classes of the event data and Variables of the SLC
+	Models []ModelSource `json:"models,omitempty" yaml:"models,omitempty" toml:"models,omitempty" validate:"omitempty,dive"`
 	// The StateConfiguration of the SLC used to dictate a State Machine.
 	State StateConfiguration `json:"state" yaml:"state" toml:"state" validate:"required"`
+	// The Parties to the SLC and the Roles they act in.
+	Parties []Party `json:"parties,omitempty" yaml:"parties,omitempty" toml:"parties,omitempty" validate:"omitempty,dive"`
 	// The Network of the SLC
 	Network Network `json:"network,omitempty" yaml:"network,omitempty" toml:"network,omitempty"`
 	// Status of the SLC. Typically used by the runtime operating the SLC.
 	Status Status `json:"status,omitempty" yaml:"status,omitempty" toml:"status,omitempty"`
+	// Signatures are the detached signatures of each party over the content digest of the SLC.
+	Signatures []Signature `json:"signatures,omitempty" yaml:"signatures,omitempty" toml:"signatures,omitempty" validate:"omitempty,dive"`
 }
 
 // Network provides a reference for remote authentication, authorization, and state management.
@@ -52,29 +61,51 @@ type Network struct {
 
 type ContractText struct {
 	// Text URL of the Smart Legal Contract
-	URL string `json:"url" yaml:"url" toml:"url" validate:"required,url"`
+	URL string `json:"url,omitempty" yaml:"url,omitempty" toml:"url,omitempty" validate:"required_without=Sources,omitempty,url"`
+	// Sources of the Text of the Smart Legal Contract, in the order they compose the text document.
+	Sources []TextSource `json:"sources,omitempty" yaml:"sources,omitempty" toml:"sources,omitempty" validate:"omitempty,dive"`
 }
 
 type TextSource struct {
 	// Name of the TextSource. E.g., "agreement-markdown, services-contract.pdf, com.decombine.decision-slc"
-	Name string `json:"name" yaml:"name" toml:"name"`
+	Name string `json:"name" yaml:"name" toml:"name" validate:"required"`
 	// Kind of the TextSource is a string value representing the REST resource of the object. E.g., "concerto, markdown, pdf"
-	Kind string `json:"kind" yaml:"kind" toml:"kind"`
+	Kind string `json:"kind" yaml:"kind" toml:"kind" validate:"required,oneof=concerto markdown pdf"`
 	// URL of the TextSource is a string value representing the URL/URI to the given resource.
-	URL string `json:"url" yaml:"url" toml:"url"`
+	URL string `json:"url" yaml:"url" toml:"url" validate:"required"`
+	// Branch of the TextSource, if the URL is a GitHub repository. E.g., "main"
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty" toml:"branch,omitempty"`
+	// Path of the TextSource relative to the URL, if the URL is a repository or directory. E.g., "text/agreement.md"
+	Path string `json:"path,omitempty" yaml:"path,omitempty" toml:"path,omitempty"`
 }
 
 // Condition is used to apply a Policy to a Smart Legal Contract State Transition.
-// A Policy may include Open Policy Agent (OPA) Rego logic.
+// A Policy may include Open Policy Agent (OPA) Rego logic. Conditions may be composed with
+// AllOf, AnyOf and Not; every part of a Condition that is declared must be satisfied.
 type Condition struct {
 	// Name of the Condition.
 	Name string `json:"name" yaml:"name" toml:"name"`
-	// Value of the Condition. This may be used to represent a specific policy query.
-	// E.g., "data.policy.allow"
+	// Value of the Condition. This may be used to represent a specific policy query, or an inline
+	// expression for Engines other than Rego. E.g., "data.policy.allow", "input.amount > 1000"
 	Value string `json:"value" yaml:"value" toml:"value"`
 	// Path to the Condition logic. E.g., "./service/condition.rego"
 	// Path is relative to the PolicySource.Directory.
 	Path string `json:"path" yaml:"path" toml:"path"`
+	// Engine evaluating the Condition policy. E.g., "rego", "cel", "jsonlogic"
+	// Defaults to "rego". For other Engines, the policy is read from Path, or declared inline as the Value.
+	Engine string `json:"engine,omitempty" yaml:"engine,omitempty" toml:"engine,omitempty"`
+	// Rego is an inline OPA Rego module evaluated in place of a policy file at Path, using Value as the query.
+	// E.g., "package payment\n\nallow if input.amount > 1000"
+	Rego string `json:"rego,omitempty" yaml:"rego,omitempty" toml:"rego,omitempty"`
+	// Roles that the acting Party must hold for the Condition to be satisfied. E.g., "admin"
+	// Roles must be declared by the Contract Parties.
+	Roles []string `json:"roles,omitempty" yaml:"roles,omitempty" toml:"roles,omitempty"`
+	// AllOf are nested Conditions that must all be satisfied.
+	AllOf []Condition `json:"allOf,omitempty" yaml:"allOf,omitempty" toml:"allOf,omitempty"`
+	// AnyOf are nested Conditions of which at least one must be satisfied.
+	AnyOf []Condition `json:"anyOf,omitempty" yaml:"anyOf,omitempty" toml:"anyOf,omitempty"`
+	// Not is a nested Condition that must not be satisfied.
+	Not *Condition `json:"not,omitempty" yaml:"not,omitempty" toml:"not,omitempty"`
 }
 
 // A GitSource is a Git repository source for Smart Legal Contracts.
@@ -95,14 +126,51 @@ type PolicySource struct {
 	Branch string `json:"branch" yaml:"branch" toml:"branch"`
 	// The directory containing the OPA policies
 	Directory string `json:"directory" yaml:"directory" toml:"directory"`
-	// The URL of the Git repository
-	URL string `json:"url" yaml:"url" toml:"url" validate:"required,url"`
+	// The URL of the Git repository. Not required if the policies are provided by a Bundle.
+	URL string `json:"url" yaml:"url" toml:"url" validate:"required_without=Bundle,omitempty,url"`
+	// Bundle is an OPA bundle providing the policies and data documents in place of the Git repository: the
+	// path to a bundle file or directory, an HTTP(S) URL, or an OCI reference. E.g., "oci://ghcr.io/acme/policies:1.0.0"
+	// Condition Paths are relative to the root of the bundle.
+	Bundle string `json:"bundle,omitempty" yaml:"bundle,omitempty" toml:"bundle,omitempty"`
+	// Verification of the signatures of the Bundle. Signed bundles cannot be loaded without Verification.
+	Verification *BundleVerification `json:"verification,omitempty" yaml:"verification,omitempty" toml:"verification,omitempty" validate:"omitempty"`
+	// Data are the data files loaded as data documents of the policies, e.g., approval matrices and thresholds.
+	Data []PolicyDataSource `json:"data,omitempty" yaml:"data,omitempty" toml:"data,omitempty" validate:"omitempty,dive"`
+}
+
+// PolicyDataSource is a JSON or YAML data file of the policies of a Contract.
+type PolicyDataSource struct {
+	// Path of the data file. As for the Path of a Condition, or an HTTP(S) URL. E.g., "./data/thresholds.yaml"
+	Path string `json:"path" yaml:"path" toml:"path" validate:"required"`
+	// Prefix is the path of the data document the file is loaded as, separated by dots or slashes. E.g.,
+	// "thresholds" for "data.thresholds". Defaults to the root data document.
+	Prefix string `json:"prefix,omitempty" yaml:"prefix,omitempty" toml:"prefix,omitempty"`
+}
+
+// BundleVerification is the public key verifying the signatures of an OPA bundle.
+type BundleVerification struct {
+	// The KeyID of the public key, matching the "keyid" of the bundle signatures.
+	KeyID string `json:"keyId" yaml:"keyId" toml:"keyId" validate:"required"`
+	// The PEM encoded PublicKey, or the secret for HMAC algorithms.
+	PublicKey string `json:"publicKey" yaml:"publicKey" toml:"publicKey" validate:"required"`
+	// The signing Algorithm. Defaults to "RS256".
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm,omitempty" toml:"algorithm,omitempty"`
+	// The Scope of the signatures, if any.
+	Scope string `json:"scope,omitempty" yaml:"scope,omitempty" toml:"scope,omitempty"`
+	// Exclude are glob patterns of the bundle files excluded from verification.
+	Exclude []string `json:"exclude,omitempty" yaml:"exclude,omitempty" toml:"exclude,omitempty"`
 }
 
 // A State is a configured Status for a Decombine Smart Legal Contract based on UML State Machine.
 type State struct {
 	// The name of the State
 	Name string `json:"name" yaml:"name" toml:"name"`
+	// Parent is the name of the superstate containing the State. A State inherits the Transitions of
+	// its superstates. E.g., "Active" for the States "Active.Suspended" and "Active.Performing"
+	Parent string `json:"parent,omitempty" yaml:"parent,omitempty" toml:"parent,omitempty"`
+	// Final indicates the State is terminal. E.g., "Fulfilled", "Terminated", "Expired"
+	// Entering a Final State completes the SLC; a Final State cannot declare Transitions.
+	Final bool `json:"final,omitempty" yaml:"final,omitempty" toml:"final,omitempty"`
 	// The actions that are executed when the State is entered
 	Entry Action `json:"entry" yaml:"entry" toml:"entry"`
 	// The actions that are executed when the State is exited
@@ -110,7 +178,7 @@ type State struct {
 	// The variables associated with the State
 	Variables []Variables `json:"variables" yaml:"variables" toml:"variables"`
 	// The transitions that are possible from this State
-	Transitions []Transition `json:"transitions" yaml:"transitions" toml:"transitions" validate:"required,gte=0,dive"`
+	Transitions []Transition `json:"transitions" yaml:"transitions" toml:"transitions" validate:"required_unless=Final true,gte=0,dive"`
 }
 
 type Variables struct {
@@ -120,10 +188,14 @@ type Variables struct {
 	Type string `json:"type" yaml:"type" toml:"type"`
 	// Default value of the Variable
 	Default string `json:"default" yaml:"default" toml:"default"`
-	// Ref is the reference to a specific source to populate the Variable
+	// Ref is the reference to a specific source to populate the Variable. The Reconciler resolves the Ref
+	// against the data of incoming events. E.g., "com.decombine.reviewer-slc.reviewer.id", "$.reviewer.id"
 	Ref string `json:"ref" yaml:"ref" toml:"ref"`
 	// Kind is a string value representing the REST resource of the object
 	Kind string `json:"kind" yaml:"kind" toml:"kind"`
+	// Value is the current value of the Variable: its value in the Contract Status, or its Default. Set by
+	// ResolvedVariables. Values may originate from event data, and are never substituted into policy source.
+	Value string `json:"-" yaml:"-" toml:"-"`
 }
 
 // A StateConfiguration is a collection of States that define the State
@@ -135,6 +207,20 @@ type StateConfiguration struct {
 	URL string `json:"url" yaml:"url" toml:"url" validate:"required,url"`
 	// The States that comprise the SLC
 	States []State `json:"states" yaml:"states" toml:"states" validate:"required,gte=1,dive"`
+	// Regions are orthogonal Regions that are active alongside the States for the lifetime of the SLC.
+	// E.g., independent "Payment", "Delivery" and "Warranty" obligations
+	Regions []Region `json:"regions,omitempty" yaml:"regions,omitempty" toml:"regions,omitempty" validate:"omitempty,dive"`
+}
+
+// A Region is an orthogonal region of the StateConfiguration with its own current State. Every Event
+// is dispatched to the StateConfiguration and to each Region. State names are unique across Regions.
+type Region struct {
+	// The Name of the Region
+	Name string `json:"name" yaml:"name" toml:"name" validate:"required"`
+	// The Initial State of the Region
+	Initial string `json:"initial" yaml:"initial" toml:"initial" validate:"required"`
+	// The States that comprise the Region
+	States []State `json:"states" yaml:"states" toml:"states" validate:"required,gte=1,dive"`
 }
 
 type Action struct {
@@ -155,15 +241,43 @@ type Transition struct {
 	Name string `json:"name" yaml:"name" toml:"name" validate:"required"`
 	// The State To which the Transition leads
 	To string `json:"to" yaml:"to" toml:"to" validate:"required"`
-	// The Event that Triggers the Transition
+	// The Event that Triggers the Transition. For timed and join Transitions, the Event is emitted
+	// by the Reconciler when the timer fires or the Regions join.
 	On string `json:"on" yaml:"on" toml:"on" validate:"required"`
+	// After is the duration after entering the State when the Transition is triggered. E.g., "72h"
+	After string `json:"after,omitempty" yaml:"after,omitempty" toml:"after,omitempty"`
+	// At is the time when the Transition is triggered, either as an RFC3339 timestamp or a reference
+	// to a State Variable holding one. E.g., "2025-12-31T23:59:59Z", "$expirationDate"
+	At string `json:"at,omitempty" yaml:"at,omitempty" toml:"at,omitempty"`
+	// Join maps Region names to the State each Region must be in for the Transition to occur. A join
+	// Transition is triggered by the Reconciler, emitting On, once every Region has reached its State.
+	// E.g., {"Payment": "Paid", "Delivery": "Delivered"}
+	Join map[string]string `json:"join,omitempty" yaml:"join,omitempty" toml:"join,omitempty"`
+	// Internal indicates the Transition remains in the State without exiting or entering it, e.g., to record
+	// a partial payment. To must be the State declaring the Transition. A Transition to its own State that is
+	// not Internal exits and re-enters the State.
+	Internal bool `json:"internal,omitempty" yaml:"internal,omitempty" toml:"internal,omitempty"`
+	// Actions executed when the Transition occurs, after the Exit actions of the exited States and before
+	// the Entry actions of the entered States
+	Actions Action `json:"actions,omitempty" yaml:"actions,omitempty" toml:"actions,omitempty"`
+	// Class is the fully qualified name of the Concerto class the event data must conform to for the
+	// Transition to occur. E.g., "com.decombine.reviewer-slc@1.0.0.ReviewCompleted"
+	Class string `json:"class,omitempty" yaml:"class,omitempty" toml:"class,omitempty"`
+	// Schema is the JSON Schema the event data must conform to. Events that do not conform are rejected
+	// before any Condition is evaluated.
+	Schema *EventSchema `json:"schema,omitempty" yaml:"schema,omitempty" toml:"schema,omitempty"`
 	// The Guard Conditions that must be satisfied for the Transition to occur
 	Conditions []Condition `json:"conditions" yaml:"conditions" toml:"conditions"`
 }
 
 type Status struct {
-	// The current state of the smart legal contract
-	CurrentState string `json:"currentState,omitempty" yaml:"currentState,omitempty" toml:"currentState,omitempty"`
+	// The current states of the smart legal contract: the State of the StateConfiguration, followed by
+	// the State of each Region
+	CurrentState StateNames `json:"currentState,omitempty" yaml:"currentState,omitempty" toml:"currentState,omitempty"`
+	// The current values of the State Variables of the smart legal contract, by Variable name
+	Variables map[string]string `json:"variables,omitempty" yaml:"variables,omitempty" toml:"variables,omitempty"`
+	// The Audit trail of changes to the running smart legal contract, oldest first
+	Audit []AuditEntry `json:"audit,omitempty" yaml:"audit,omitempty" toml:"audit,omitempty"`
 	// The source state of the smart legal contract
 	SourceState string `json:"sourceState,omitempty" yaml:"sourceState,omitempty" toml:"sourceState,omitempty"`
 	// The policy state of the smart legal contract
@@ -172,6 +286,58 @@ type Status struct {
 	WorkloadState string `json:"workloadState,omitempty" yaml:"workloadState,omitempty" toml:"workloadState,omitempty"`
 }
 
+// StateNames are the names of the current states of a smart legal contract. A single name is decoded as one
+// state, so that statuses persisted before Regions were introduced can still be loaded.
+type StateNames []string
+
+func (n *StateNames) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*n = StateNames{name}
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+func (n *StateNames) UnmarshalYAML(data []byte) error {
+	var name string
+	if err := yaml.Unmarshal(data, &name); err == nil {
+		*n = StateNames{name}
+		return nil
+	}
+	var names []string
+	if err := yaml.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*n = names
+	return nil
+}
+
+func (n *StateNames) UnmarshalTOML(data interface{}) error {
+	switch v := data.(type) {
+	case string:
+		*n = StateNames{v}
+	case []interface{}:
+		names := make(StateNames, 0, len(v))
+		for _, name := range v {
+			s, ok := name.(string)
+			if !ok {
+				return fmt.Errorf("invalid state name %v", name)
+			}
+			names = append(names, s)
+		}
+		*n = names
+	default:
+		return fmt.Errorf("invalid current state %v", data)
+	}
+	return nil
+}
+
 // TODO: Convert State Configuration errors to a custom Error type.
 
 var (
@@ -179,7 +345,7 @@ var (
 )
 
 func (c *Contract) GetState(name string) (State, error) {
-	for _, s := range c.State.States {
+	for _, s := range c.States() {
 		if s.Name == name {
 			return s, nil
 		}
@@ -200,7 +366,7 @@ func New() Contract {
 // GetVariables returns the Variables for a given State. Variables can be
 // used to store values associated with State Configuration.
 func (c *Contract) GetVariables(state string) ([]Variables, error) {
-	for _, s := range c.State.States {
+	for _, s := range c.States() {
 		if s.Name == state {
 			return s.Variables, nil
 		}
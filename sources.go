@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -55,6 +56,8 @@ type ClientOpts struct {
 	OCICreds RepoCredential
 	// OCIPullPath is the target to pull the OCI artifact to.
 	OCIPullPath string
+	// GitHubPAT is the Personal Access Token (PAT) used to retrieve files from private GitHub repositories.
+	GitHubPAT string
 }
 
 func WithOCI(registry, repo, tag string) ClientOpts {
@@ -73,6 +76,40 @@ func WithOCICreds(credential RepoCredential) ClientOpts {
 	}
 }
 
+func WithGitHubPAT(token string) ClientOpts {
+	return ClientOpts{
+		GitHubPAT: token,
+	}
+}
+
+// GetSourceFile retrieves a file referenced by a Contract. A GitHub repository URL is resolved with the branch
+// and path of the file, other HTTP(S) URLs are fetched, and any other URL is a directory or file on the file system.
+func GetSourceFile(ctx context.Context, uri, branch, path string, opts ...ClientOpts) ([]byte, error) {
+	var options ClientOpts
+	for _, opt := range opts {
+		if opt.GitHubPAT != "" {
+			options.GitHubPAT = opt.GitHubPAT
+		}
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case u.Host == "github.com" && path != "":
+		return getPolicyFile(ctx, uri, branch, options.GitHubPAT, path)
+	case u.Scheme == "http" || u.Scheme == "https":
+		if path != "" {
+			uri = strings.TrimSuffix(uri, "/") + "/" + strings.TrimPrefix(path, "/")
+		}
+		return fetchDocument(ctx, uri)
+	case u.Scheme == "oci":
+		return nil, fmt.Errorf("unsupported source %s: OCI artifacts are not yet supported", uri)
+	}
+	return os.ReadFile(filepath.Join(strings.TrimPrefix(uri, "file://"), path))
+}
+
 func GetFSContract(path string) (*Contract, error) {
 	f, err := os.Open(path)
 	if err != nil {
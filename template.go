@@ -0,0 +1,218 @@
+package slc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/goccy/go-yaml"
+	"github.com/google/uuid"
+)
+
+const (
+	ParameterTypeString  = "string"
+	ParameterTypeNumber  = "number"
+	ParameterTypeInteger = "integer"
+	ParameterTypeBoolean = "boolean"
+)
+
+var (
+	ErrInvalidTemplate  = errors.New("contract template is invalid")
+	ErrMissingParameter = errors.New("required template parameter is missing")
+	ErrUnknownParameter = errors.New("template parameter is not declared")
+	ErrInvalidParameter = errors.New("template parameter value is invalid")
+)
+
+// placeholder matches the placeholders of a ContractTemplate. E.g., "${buyerName}"
+var placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ContractTemplate is a Contract definition with Parameters, used to create any number of Contracts. Placeholders
+// referencing a Parameter, e.g., "${buyerName}", may be used in any string of the Contract, including the names,
+// Policy, Variables, Action specs and Text. Placeholders not referencing a Parameter are left as is, so that
+// post-build substitutions of Kustomizations are preserved.
+type ContractTemplate struct {
+	// MediaType of the ContractTemplate artifact. See MediaTypeDecombineTemplateSlcV2JSON.
+	MediaType string `json:"mediaType,omitempty" yaml:"mediaType,omitempty" toml:"mediaType,omitempty" validate:"omitempty,eq=application/vnd.decombine.template.slc.v1+json"`
+	// The friendly Name of the ContractTemplate
+	Name string `json:"name" yaml:"name" toml:"name" validate:"required"`
+	// The Version of the ContractTemplate
+	Version string `json:"version" yaml:"version" toml:"version" validate:"required,semver"`
+	// The Parameters of the ContractTemplate
+	Parameters []TemplateParameter `json:"parameters,omitempty" yaml:"parameters,omitempty" toml:"parameters,omitempty" validate:"omitempty,dive"`
+	// The Contract with placeholders. It is validated once instantiated.
+	Contract map[string]interface{} `json:"contract" yaml:"contract" toml:"contract" validate:"required"`
+}
+
+// TemplateParameter is a Parameter of a ContractTemplate.
+type TemplateParameter struct {
+	// The Name of the Parameter referenced by placeholders. E.g., "buyerName"
+	Name string `json:"name" yaml:"name" toml:"name" validate:"required"`
+	// The Type of the Parameter, either "string", "number", "integer" or "boolean". Defaults to "string".
+	Type string `json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty" validate:"omitempty,oneof=string number integer boolean"`
+	// Required indicates the Parameter must be provided if it has no Default.
+	Required bool `json:"required,omitempty" yaml:"required,omitempty" toml:"required,omitempty"`
+	// The Default value of the Parameter
+	Default string `json:"default,omitempty" yaml:"default,omitempty" toml:"default,omitempty"`
+	// Description of the Parameter
+	Description string `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+}
+
+// GetFSTemplate retrieves a ContractTemplate from the file system, in JSON or YAML.
+func GetFSTemplate(path string) (*ContractTemplate, error) {
+	input, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch getFileType(path) {
+	case JSON:
+		return ValidateTemplateJSONPayload(input)
+	case YAML:
+		return ValidateTemplateYAMLPayload(input)
+	}
+	return nil, errors.New("unknown or unsupported file format")
+}
+
+// ValidateTemplateJSONPayload validates a JSON payload input against the ContractTemplate struct.
+func ValidateTemplateJSONPayload(in []byte) (*ContractTemplate, error) {
+	var t ContractTemplate
+	if err := json.Unmarshal(in, &t); err != nil {
+		return nil, ErrCannotUnmarshalJSON
+	}
+	return &t, t.Validate()
+}
+
+// ValidateTemplateYAMLPayload validates a YAML payload input against the ContractTemplate struct.
+func ValidateTemplateYAMLPayload(in []byte) (*ContractTemplate, error) {
+	var t ContractTemplate
+	if err := yaml.Unmarshal(in, &t); err != nil {
+		return nil, ErrCannotUnmarshalYAML
+	}
+	return &t, t.Validate()
+}
+
+// Validate validates the ContractTemplate, that its Parameters are unique, and that their Defaults are of their Type.
+func (t *ContractTemplate) Validate() error {
+	if err := validator.New(validator.WithRequiredStructEnabled()).Struct(t); err != nil {
+		return err
+	}
+	declared := make(map[string]bool)
+	for _, p := range t.Parameters {
+		if !placeholder.MatchString("${" + p.Name + "}") {
+			return fmt.Errorf("%w: invalid parameter name %q", ErrInvalidTemplate, p.Name)
+		}
+		if declared[p.Name] {
+			return fmt.Errorf("%w: duplicate parameter %s", ErrInvalidTemplate, p.Name)
+		}
+		declared[p.Name] = true
+		if p.Default == "" {
+			continue
+		}
+		if _, err := p.value(p.Default); err != nil {
+			return fmt.Errorf("%w: default of %s: %v", ErrInvalidTemplate, p.Name, err)
+		}
+	}
+	return nil
+}
+
+// value formats a value of the Parameter, validating that it is of the Parameter Type. Strings are parsed.
+func (p TemplateParameter) value(v interface{}) (string, error) {
+	var s string
+	switch t := v.(type) {
+	case string:
+		s = t
+	case bool:
+		s = strconv.FormatBool(t)
+	case float64:
+		s = strconv.FormatFloat(t, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(t), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s = fmt.Sprint(t)
+	default:
+		return "", fmt.Errorf("%w: %s: unsupported value %v", ErrInvalidParameter, p.Name, v)
+	}
+
+	switch p.Type {
+	case ParameterTypeNumber:
+		if _, err := strconv.ParseFloat(s, 64); err != nil {
+			return "", fmt.Errorf("%w: %s: %q is not a number", ErrInvalidParameter, p.Name, s)
+		}
+	case ParameterTypeInteger:
+		n, err := strconv.ParseFloat(s, 64)
+		if err != nil || n != math.Trunc(n) {
+			return "", fmt.Errorf("%w: %s: %q is not an integer", ErrInvalidParameter, p.Name, s)
+		}
+	case ParameterTypeBoolean:
+		if _, err := strconv.ParseBool(s); err != nil {
+			return "", fmt.Errorf("%w: %s: %q is not a boolean", ErrInvalidParameter, p.Name, s)
+		}
+	}
+	return s, nil
+}
+
+// Instantiate creates a Contract from the ContractTemplate by replacing the placeholders of each Parameter with
+// its value in params, or its Default. The Contract is validated, and is identified by a new UUID.
+func (t *ContractTemplate) Instantiate(params map[string]interface{}) (*Contract, error) {
+	values := make(map[string]string)
+	for _, p := range t.Parameters {
+		v, ok := params[p.Name]
+		if !ok {
+			if p.Default == "" && p.Required {
+				return nil, fmt.Errorf("%w: %s", ErrMissingParameter, p.Name)
+			}
+			v = p.Default
+		}
+		s, err := p.value(v)
+		if err != nil {
+			return nil, err
+		}
+		values[p.Name] = s
+	}
+	for name := range params {
+		if _, ok := values[name]; !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownParameter, name)
+		}
+	}
+
+	payload, err := json.Marshal(substitutePlaceholders(t.Contract, values))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidTemplate, err)
+	}
+	c, err := ValidateJSONPayload(payload)
+	if err != nil {
+		return nil, err
+	}
+	c.ID = uuid.New().String()
+	return c, nil
+}
+
+// substitutePlaceholders replaces the placeholders of the values in every string of a document.
+func substitutePlaceholders(document interface{}, values map[string]string) interface{} {
+	switch d := document.(type) {
+	case string:
+		return placeholder.ReplaceAllStringFunc(d, func(match string) string {
+			if v, ok := values[match[2:len(match)-1]]; ok {
+				return v
+			}
+			return match
+		})
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			out[substitutePlaceholders(k, values).(string)] = substitutePlaceholders(v, values)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(d))
+		for i, v := range d {
+			out[i] = substitutePlaceholders(v, values)
+		}
+		return out
+	}
+	return document
+}
@@ -0,0 +1,93 @@
+package slc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestContractTemplateInstantiate(t *testing.T) {
+	tmpl, err := GetFSTemplate("./tests/template_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := tmpl.Instantiate(map[string]interface{}{"buyer": "acme", "buyerName": "Acme Corporation", "amount": 5000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.ID == "" {
+		t.Fatal("expected the contract to have an ID")
+	}
+	if c.Name != "Purchase Agreement with Acme Corporation" {
+		t.Fatalf("unexpected name %s", c.Name)
+	}
+	if p, err := c.GetParty("acme"); err != nil || p.Name != "Acme Corporation" {
+		t.Fatalf("unexpected party %v: %v", p, err)
+	}
+	vars, _ := c.GetVariables("Draft")
+	if vars[0].Default != "5000" || vars[1].Default != "USD" {
+		t.Fatalf("unexpected variables %v", vars)
+	}
+	if value := c.State.States[0].Transitions[0].Conditions[0].Value; value != "variables.amount <= 5000" {
+		t.Fatalf("unexpected condition %s", value)
+	}
+	action := c.State.States[1].Entry.KubernetesActions[0]
+	if action.Name != "approved-acme" || action.Namespace != "default" {
+		t.Fatalf("unexpected action %s/%s", action.Namespace, action.Name)
+	}
+	// Placeholders not referencing a Parameter are left for post-build substitution.
+	if s := action.KustomizationSpec.PostBuild.Substitute["reviewer"]; s != "${reviewerUniqueId}" {
+		t.Fatalf("unexpected substitution %s", s)
+	}
+
+	other, err := tmpl.Instantiate(map[string]interface{}{"buyer": "globex", "buyerName": "Globex", "amount": "250.50", "currency": "EUR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.ID == c.ID {
+		t.Fatal("expected contracts to have distinct IDs")
+	}
+
+	tests := []struct {
+		name   string
+		params map[string]interface{}
+		err    error
+	}{
+		{name: "Missing parameter", params: map[string]interface{}{"buyer": "acme", "amount": 1}, err: ErrMissingParameter},
+		{name: "Unknown parameter", params: map[string]interface{}{"buyer": "acme", "buyerName": "Acme", "amount": 1, "seller": "globex"}, err: ErrUnknownParameter},
+		{name: "Invalid parameter", params: map[string]interface{}{"buyer": "acme", "buyerName": "Acme", "amount": "a lot"}, err: ErrInvalidParameter},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := tmpl.Instantiate(test.params); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
+func TestContractTemplateValidate(t *testing.T) {
+	contract := map[string]interface{}{"name": "${name}"}
+	tests := []struct {
+		name     string
+		template ContractTemplate
+		err      error
+	}{
+		{name: "Valid", template: ContractTemplate{Name: "t", Version: "1.0.0", Contract: contract, Parameters: []TemplateParameter{{Name: "name"}}}},
+		{name: "Duplicate parameter", template: ContractTemplate{Name: "t", Version: "1.0.0", Contract: contract, Parameters: []TemplateParameter{{Name: "name"}, {Name: "name"}}}, err: ErrInvalidTemplate},
+		{name: "Invalid parameter name", template: ContractTemplate{Name: "t", Version: "1.0.0", Contract: contract, Parameters: []TemplateParameter{{Name: "buyer-name"}}}, err: ErrInvalidTemplate},
+		{name: "Invalid default", template: ContractTemplate{Name: "t", Version: "1.0.0", Contract: contract, Parameters: []TemplateParameter{{Name: "count", Type: ParameterTypeInteger, Default: "1.5"}}}, err: ErrInvalidTemplate},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.template.Validate(); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+
+	invalid := ContractTemplate{MediaType: MediaTypeConcertoDataV2, Name: "t", Version: "1.0.0", Contract: contract}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("expected an error for the media type")
+	}
+}
@@ -0,0 +1,10 @@
+package party.role
+
+import rego.v1
+
+default allow := false
+
+allow if {
+	input.party.role == "buyer"
+	input.accepted == true
+}
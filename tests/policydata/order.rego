@@ -0,0 +1,7 @@
+package order
+
+import rego.v1
+
+default allow := false
+
+allow if input.amount <= data.thresholds.max_amount
@@ -0,0 +1,11 @@
+package order_test
+
+import rego.v1
+
+import data.order
+
+test_within_threshold if order.allow with input as {"amount": 1000}
+
+test_over_threshold if not order.allow with input as {"amount": 1001}
+
+test_directory_data if data.limits.min_amount == 10
@@ -0,0 +1,7 @@
+package order.small
+
+import rego.v1
+
+default allow := false
+
+allow if input.amount < 1000
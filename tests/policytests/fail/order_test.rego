@@ -0,0 +1,9 @@
+package order.small_test
+
+import rego.v1
+
+import data.order.small
+
+test_small_order_allowed if small.allow with input as {"amount": 500}
+
+test_limit_allowed if small.allow with input as {"amount": 1000}
@@ -0,0 +1,5 @@
+package lib.amounts
+
+import rego.v1
+
+small(amount) if amount <= 1000
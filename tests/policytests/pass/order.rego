@@ -0,0 +1,9 @@
+package order.small
+
+import rego.v1
+
+import data.lib.amounts
+
+default allow := false
+
+allow if amounts.small(input.amount)
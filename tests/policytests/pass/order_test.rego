@@ -0,0 +1,11 @@
+package order.small_test
+
+import rego.v1
+
+import data.order.small
+
+test_small_order_allowed if small.allow with input as {"amount": 500}
+
+test_large_order_denied if not small.allow with input as {"amount": 5000}
+
+todo_test_flagged_order_denied if false
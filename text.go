@@ -0,0 +1,151 @@
+package slc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+const (
+	// TextKindMarkdown is the Kind of TextSources that are Markdown templates.
+	TextKindMarkdown = "markdown"
+	// TextKindPDF is the Kind of TextSources that are PDF documents.
+	TextKindPDF = "pdf"
+	// TextKindConcerto is the Kind of TextSources that are Concerto data.
+	TextKindConcerto = "concerto"
+)
+
+var (
+	ErrNoTextSources = errors.New("contract declares no text sources")
+	ErrInvalidText   = errors.New("contract text is invalid")
+)
+
+// TextDocument is the content of a TextSource.
+type TextDocument struct {
+	Source  TextSource
+	Content []byte
+}
+
+// TextData is the data Markdown TextSources are rendered with. E.g., "{{ .Variables.amount }}",
+// "{{ .Parties.buyer.Name }}", "{{ (role \"reviewer\").Name }}"
+type TextData struct {
+	// The ID of the SLC
+	ID string
+	// The Name of the SLC
+	Name string
+	// The current states of the SLC. See Status.CurrentState.
+	State []string
+	// The current value of each Variable of the SLC, by Variable name, or its Default if it has no value.
+	Variables map[string]string
+	// The Parties of the SLC, by Party ID
+	Parties map[string]Party
+}
+
+// FetchText retrieves the TextSources of the Contract Text. See GetSourceFile.
+func (c *Contract) FetchText(ctx context.Context, opts ...ClientOpts) ([]TextDocument, error) {
+	var documents []TextDocument
+	for _, src := range c.Text.Sources {
+		content, err := GetSourceFile(ctx, src.URL, src.Branch, src.Path, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve text %s: %w", src.Name, err)
+		}
+		documents = append(documents, TextDocument{Source: src, Content: content})
+	}
+	return documents, nil
+}
+
+// textDigestContent returns the content of the Contract Text included in its content digest: the name and
+// SHA-256 digest of each TextDocument, one per line.
+func textDigestContent(documents []TextDocument) []byte {
+	var b bytes.Buffer
+	for _, doc := range documents {
+		fmt.Fprintf(&b, "%s %s\n", sha256Hex([]byte(doc.Source.Name)), sha256Hex(doc.Content))
+	}
+	return b.Bytes()
+}
+
+// TextData returns the data Markdown TextSources are rendered with, reflecting the current Status.
+func (c *Contract) TextData() TextData {
+	data := TextData{
+		ID:        c.ID,
+		Name:      c.Name,
+		State:     c.Status.CurrentState,
+		Variables: make(map[string]string),
+		Parties:   make(map[string]Party),
+	}
+	for _, s := range c.States() {
+		for _, v := range s.Variables {
+			if _, ok := data.Variables[v.Name]; !ok {
+				data.Variables[v.Name] = v.Default
+			}
+		}
+	}
+	for name, value := range c.Status.Variables {
+		data.Variables[name] = value
+	}
+	for _, p := range c.Parties {
+		data.Parties[p.ID] = p
+	}
+	return data
+}
+
+// RenderText renders the Markdown TextSources of the Contract Text with the current Variables and Parties
+// into a single Markdown document, in the order the TextSources are declared. PDF and Concerto TextSources
+// are not part of the rendered document. Templates referencing an undeclared Variable or Party fail to render.
+func (c *Contract) RenderText(ctx context.Context, opts ...ClientOpts) ([]byte, error) {
+	if len(c.Text.Sources) == 0 {
+		return nil, ErrNoTextSources
+	}
+	documents, err := c.FetchText(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var sections [][]byte
+	for _, doc := range documents {
+		if doc.Source.Kind != TextKindMarkdown {
+			continue
+		}
+		section, err := c.renderMarkdown(doc)
+		if err != nil {
+			return nil, err
+		}
+		sections = append(sections, bytes.TrimSpace(section))
+	}
+	return append(bytes.Join(sections, []byte("\n\n")), '\n'), nil
+}
+
+// renderMarkdown renders a Markdown template.
+func (c *Contract) renderMarkdown(doc TextDocument) ([]byte, error) {
+	tmpl, err := c.textTemplate(doc.Source.Name, string(doc.Content))
+	if err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	if err = tmpl.Execute(&b, c.TextData()); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidText, doc.Source.Name, err)
+	}
+	return b.Bytes(), nil
+}
+
+// textTemplate parses a Markdown template. The role function returns the first Party acting in a role.
+func (c *Contract) textTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"role": func(role string) (Party, error) {
+			for _, p := range c.Parties {
+				if p.Role == role {
+					return p, nil
+				}
+			}
+			return Party{}, fmt.Errorf("no party acts in role %s", role)
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrInvalidText, name, err)
+	}
+	return tmpl, nil
+}
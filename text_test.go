@@ -0,0 +1,54 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestRenderText(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/text_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	documents, err := c.FetchText(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 3 || documents[1].Source.Kind != TextKindPDF {
+		t.Fatalf("unexpected documents: %v", documents)
+	}
+
+	c.Status.CurrentState = []string{"Draft"}
+	c.Status.Variables = map[string]string{"amount": "5000"}
+	text, err := c.RenderText(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Purchase Agreement
+
+This Agreement is entered into by Acme Corporation ("Buyer") and Globex Inc ("Seller").
+
+The Buyer shall pay the Seller 5000 USD.
+
+## Status
+
+- Draft
+`
+	if string(text) != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, text)
+	}
+
+	// Templates referencing undeclared Parties fail to render.
+	c.Parties = c.Parties[1:]
+	if _, err = c.RenderText(ctx); !errors.Is(err, ErrInvalidText) {
+		t.Fatalf("expected %v, got %v", ErrInvalidText, err)
+	}
+
+	c.Text.Sources = nil
+	if _, err = c.RenderText(ctx); !errors.Is(err, ErrNoTextSources) {
+		t.Fatalf("expected %v, got %v", ErrNoTextSources, err)
+	}
+}
@@ -0,0 +1,449 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	// TimerEventSource is the CloudEvent source of trigger events emitted by timed Transitions.
+	TimerEventSource = "decombine/slc/timer"
+	// defaultTimerResolution is the maximum interval between checks of the TimerStore for due Timers.
+	defaultTimerResolution = time.Second
+)
+
+var (
+	ErrTimerNotFound  = errors.New("timer not found")
+	ErrInvalidTimer   = errors.New("transition timer is invalid")
+	ErrTimerAmbiguous = errors.New("transition cannot declare both after and at")
+)
+
+// A Clock provides the current time to the timer Scheduler. Clock can be replaced to test
+// timed Transitions without waiting.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the default Clock using the system time.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// A Timer is a scheduled trigger of a timed Transition.
+type Timer struct {
+	// ID of the Timer, unique per State and Transition.
+	ID string `json:"id"`
+	// State in which the Timer was scheduled.
+	State string `json:"state"`
+	// Transition Name scheduled by the Timer.
+	Transition string `json:"transition"`
+	// Event type emitted when the Timer fires.
+	Event string `json:"event"`
+	// FireAt is when the Timer is due.
+	FireAt time.Time `json:"fireAt"`
+}
+
+// timerID returns the ID of the Timer for a State Transition. The ID is safe for use as a JetStream key.
+func timerID(state, transition string) string {
+	return "timer." + sha256Hex([]byte(state + "\x00" + transition))[:32]
+}
+
+// A TimerStore persists Timers so that timed Transitions survive a restart of the Reconciler.
+type TimerStore interface {
+	Get(ctx context.Context, id string) (Timer, error)
+	Put(ctx context.Context, timer Timer) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]Timer, error)
+}
+
+// MemoryTimerStore is a TimerStore held in memory. Timers do not survive a restart.
+type MemoryTimerStore struct {
+	mu     sync.Mutex
+	timers map[string]Timer
+}
+
+// NewMemoryTimerStore creates an empty MemoryTimerStore.
+func NewMemoryTimerStore() *MemoryTimerStore {
+	return &MemoryTimerStore{timers: make(map[string]Timer)}
+}
+
+func (s *MemoryTimerStore) Get(_ context.Context, id string) (Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.timers[id]
+	if !ok {
+		return Timer{}, ErrTimerNotFound
+	}
+	return t, nil
+}
+
+func (s *MemoryTimerStore) Put(_ context.Context, timer Timer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timers[timer.ID] = timer
+	return nil
+}
+
+func (s *MemoryTimerStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.timers, id)
+	return nil
+}
+
+func (s *MemoryTimerStore) List(_ context.Context) ([]Timer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Timer, 0, len(s.timers))
+	for _, t := range s.timers {
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// KeyValueTimerStore is a TimerStore persisted in a JetStream Key-Value bucket.
+type KeyValueTimerStore struct {
+	kv jetstream.KeyValue
+}
+
+// NewKeyValueTimerStore creates a KeyValueTimerStore using the given bucket. The bucket should be
+// dedicated to a single Contract.
+func NewKeyValueTimerStore(kv jetstream.KeyValue) *KeyValueTimerStore {
+	return &KeyValueTimerStore{kv: kv}
+}
+
+func (s *KeyValueTimerStore) Get(ctx context.Context, id string) (Timer, error) {
+	entry, err := s.kv.Get(ctx, id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return Timer{}, ErrTimerNotFound
+	}
+	if err != nil {
+		return Timer{}, err
+	}
+	var t Timer
+	if err = json.Unmarshal(entry.Value(), &t); err != nil {
+		return Timer{}, err
+	}
+	return t, nil
+}
+
+func (s *KeyValueTimerStore) Put(ctx context.Context, timer Timer) error {
+	payload, err := json.Marshal(timer)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(ctx, timer.ID, payload)
+	return err
+}
+
+func (s *KeyValueTimerStore) Delete(ctx context.Context, id string) error {
+	err := s.kv.Delete(ctx, id)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *KeyValueTimerStore) List(ctx context.Context) ([]Timer, error) {
+	keys, err := s.kv.ListKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = keys.Stop()
+	}()
+
+	var out []Timer
+	for k := range keys.Keys() {
+		if !strings.HasPrefix(k, "timer.") {
+			continue
+		}
+		t, err := s.Get(ctx, k)
+		if errors.Is(err, ErrTimerNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	return out, nil
+}
+
+// IsTimed determines if the Transition is triggered by a timer.
+func (t Transition) IsTimed() bool {
+	return t.After != "" || t.At != ""
+}
+
+// fireAt returns when a timed Transition is due after entering a State at the given time. At may
+// reference a State Variable holding an RFC3339 timestamp, e.g. "$paymentDue", resolved to its current value.
+func (t Transition) fireAt(entered time.Time, variables []Variables) (time.Time, error) {
+	if t.After != "" && t.At != "" {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrTimerAmbiguous, t.Name)
+	}
+	if t.After != "" {
+		d, err := time.ParseDuration(t.After)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s: %v", ErrInvalidTimer, t.Name, err)
+		}
+		return entered.Add(d), nil
+	}
+
+	at := t.At
+	if strings.HasPrefix(at, "$") {
+		name := strings.TrimPrefix(at, "$")
+		at = ""
+		for _, v := range variables {
+			if v.Name == name {
+				at = v.current()
+			}
+		}
+		if at == "" {
+			return time.Time{}, fmt.Errorf("%w: %s: variable %s has no value", ErrInvalidTimer, t.Name, name)
+		}
+	}
+	ts, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s: %v", ErrInvalidTimer, t.Name, err)
+	}
+	return ts, nil
+}
+
+// ValidateTimers validates that timed Transitions declare either After as a duration, or At as an
+// RFC3339 timestamp or a reference to a Variable of the State.
+func (c *Contract) ValidateTimers() error {
+	for _, s := range c.States() {
+		for _, t := range s.Transitions {
+			if !t.IsTimed() {
+				continue
+			}
+			if t.After != "" && t.At != "" {
+				return fmt.Errorf("%w: %s (state %s)", ErrTimerAmbiguous, t.Name, s.Name)
+			}
+			if strings.HasPrefix(t.At, "$") {
+				name := strings.TrimPrefix(t.At, "$")
+				found := false
+				for _, v := range s.Variables {
+					if v.Name == name {
+						found = true
+					}
+				}
+				if !found {
+					return fmt.Errorf("%w: %s (state %s): variable %s not declared", ErrInvalidTimer, t.Name, s.Name, name)
+				}
+				continue
+			}
+			if _, err := t.fireAt(time.Time{}, nil); err != nil {
+				return fmt.Errorf("%w (state %s)", err, s.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Scheduler schedules the timed Transitions of the current State and fires them when due.
+type Scheduler struct {
+	store      TimerStore
+	clock      Clock
+	resolution time.Duration
+	fire       func(ctx context.Context, t Timer) error
+}
+
+// NewScheduler creates a Scheduler that calls fire for each due Timer. A Timer is removed from the
+// store when it fires, and restored if fire returns an error.
+func NewScheduler(store TimerStore, clock Clock, fire func(ctx context.Context, t Timer) error) *Scheduler {
+	if store == nil {
+		store = NewMemoryTimerStore()
+	}
+	if clock == nil {
+		clock = systemClock{}
+	}
+	return &Scheduler{
+		store:      store,
+		clock:      clock,
+		resolution: defaultTimerResolution,
+		fire:       fire,
+	}
+}
+
+// Schedule persists a Timer for each timed Transition of the State. Timers already in the store,
+// e.g. from before a restart, keep their original due time. Variables referenced by At are resolved against
+// the Variables of the State, e.g., as returned by Contract.ResolvedVariables.
+func (s *Scheduler) Schedule(ctx context.Context, state State) error {
+	now := s.clock.Now()
+	for _, t := range state.Transitions {
+		if !t.IsTimed() {
+			continue
+		}
+		id := timerID(state.Name, t.Name)
+		if _, err := s.store.Get(ctx, id); err == nil {
+			continue
+		} else if !errors.Is(err, ErrTimerNotFound) {
+			return err
+		}
+		at, err := t.fireAt(now, state.Variables)
+		if err != nil {
+			return err
+		}
+		timer := Timer{ID: id, State: state.Name, Transition: t.Name, Event: t.On, FireAt: at}
+		if err = s.store.Put(ctx, timer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cancel removes all Timers scheduled in the State.
+func (s *Scheduler) Cancel(ctx context.Context, state string) error {
+	timers, err := s.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	for _, t := range timers {
+		if t.State != state {
+			continue
+		}
+		if err = s.store.Delete(ctx, t.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FireDue fires every Timer that is due according to the Clock, in order of due time, and returns
+// the number of Timers fired.
+func (s *Scheduler) FireDue(ctx context.Context) (int, error) {
+	timers, err := s.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].FireAt.Before(timers[j].FireAt)
+	})
+
+	now := s.clock.Now()
+	fired := 0
+	for _, t := range timers {
+		if t.FireAt.After(now) {
+			break
+		}
+		// The Timer is removed before firing so that a Timer rescheduled by the fired Transition
+		// is not removed. It is restored if it cannot be fired.
+		if err = s.store.Delete(ctx, t.ID); err != nil {
+			return fired, err
+		}
+		if err = s.fire(ctx, t); err != nil {
+			return fired, errors.Join(err, s.store.Put(ctx, t))
+		}
+		fired++
+	}
+	return fired, nil
+}
+
+// Run fires due Timers until the context is cancelled.
+func (s *Scheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.resolution)
+	defer ticker.Stop()
+	for {
+		if _, err := s.FireDue(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// fireTimer hands a due Timer to the Reconciler event loop so that it is consumed in order with
+// other events.
+func (r *Reconciler) fireTimer(ctx context.Context, t Timer) error {
+	select {
+	case r.timerChannel <- t:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// consumeTimer emits the trigger event of a fired Timer and consumes it for the timed Transition.
+// Timers scheduled in a State that is no longer current are ignored.
+func (r *Reconciler) consumeTimer(ctx context.Context, t Timer) error {
+	region := r.Contract.RegionOf(t.State)
+	state, err := r.stateOf(ctx, region)
+	if err != nil {
+		return err
+	}
+	if !r.Contract.IsSubstateOf(state.Name, t.State) {
+		r.Logger.Debug("Ignoring timer for previous state", "state", t.State, "transition", t.Transition)
+		return nil
+	}
+
+	// The Timer may have been scheduled by a superstate of the current State.
+	declaring, err := r.Contract.GetState(t.State)
+	if err != nil {
+		return err
+	}
+	var eligible []Transition
+	for _, tr := range declaring.Transitions {
+		if tr.Name == t.Transition {
+			eligible = append(eligible, tr)
+		}
+	}
+
+	evt, err := r.Contract.CreateEvent(t.Event, TimerEventSource)
+	if err != nil {
+		return err
+	}
+	if err = evt.SetData(cloudevents.ApplicationJSON, map[string]interface{}{"timer": t}); err != nil {
+		return err
+	}
+
+	if r.Config.PublishSubject != "" && r.Stream != nil {
+		payload, _ := evt.MarshalJSON()
+		if _, err = r.Stream.Publish(ctx, r.Config.PublishSubject, payload); err != nil {
+			r.Logger.Error("Error publishing timer event", "error", err)
+		}
+	}
+
+	return r.consumeEvent(ctx, &evt, map[string][]Transition{region: eligible}, true)
+}
+
+// rescheduleTimers cancels the Timers of the exited States and schedules the Timers of the entered States.
+// Timers of superstates that remain active are kept.
+func (r *Reconciler) rescheduleTimers(ctx context.Context, exited, entered []State) error {
+	for _, s := range exited {
+		if err := r.Scheduler.Cancel(ctx, s.Name); err != nil {
+			return err
+		}
+	}
+	for _, s := range entered {
+		if err := r.schedule(ctx, s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schedule schedules the timed Transitions of a State, resolving the Variables they reference to their current
+// values in the Contract Status.
+func (r *Reconciler) schedule(ctx context.Context, s State) error {
+	variables, err := r.Contract.ResolvedVariables(s.Name)
+	if err != nil {
+		return err
+	}
+	s.Variables = variables
+	return r.Scheduler.Schedule(ctx, s)
+}
@@ -0,0 +1,222 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestSchedulerFireDue(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := NewMemoryTimerStore()
+
+	c, err := GetFSContract("./tests/timers_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	draft, _ := c.GetState("Draft")
+	inProcess, _ := c.GetState("In Process")
+
+	var fired []Timer
+	s := NewScheduler(store, clock, func(_ context.Context, tm Timer) error {
+		fired = append(fired, tm)
+		return nil
+	})
+
+	if err = s.Schedule(ctx, inProcess); err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted Scheduler keeps the persisted due time.
+	clock.Advance(24 * time.Hour)
+	s = NewScheduler(store, clock, s.fire)
+	if err = s.Schedule(ctx, inProcess); err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(47 * time.Hour)
+	if n, _ := s.FireDue(ctx); n != 0 {
+		t.Fatalf("expected no timers to fire, got %d", n)
+	}
+	clock.Advance(time.Hour)
+	if n, _ := s.FireDue(ctx); n != 1 {
+		t.Fatalf("expected 1 timer to fire, got %d", n)
+	}
+	if fired[0].Event != "com.decombine.payment.overdue" {
+		t.Fatalf("unexpected event %s", fired[0].Event)
+	}
+	if timers, _ := store.List(ctx); len(timers) != 0 {
+		t.Fatalf("expected fired timer to be removed, got %v", timers)
+	}
+
+	// At references a State Variable.
+	if err = s.Schedule(ctx, draft); err != nil {
+		t.Fatal(err)
+	}
+	tm, err := store.Get(ctx, timerID("Draft", "Signing Deadline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tm.FireAt.Equal(time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected due time %s", tm.FireAt)
+	}
+	if err = s.Cancel(ctx, "Draft"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Get(ctx, tm.ID); !errors.Is(err, ErrTimerNotFound) {
+		t.Fatalf("expected %v, got %v", ErrTimerNotFound, err)
+	}
+}
+
+func TestReconcilerConsumeTimer(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	c, err := GetFSContract("./tests/timers_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "In Process", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithClock(clock), WithReconcilerLogger(logger))
+
+	state, _ := c.GetState("In Process")
+	if err = r.Scheduler.Schedule(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+
+	// External events, even those claiming to originate from the Scheduler, do not fire timed Transitions.
+	for _, source := range []string{"test", TimerEventSource} {
+		evt, err := c.CreateEvent("com.decombine.payment.overdue", source)
+		if err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+		if current, _ := sm.State(ctx); current != "In Process" {
+			t.Fatalf("expected state In Process, got %v", current)
+		}
+	}
+	if err = sm.FireCtx(ctx, "com.decombine.payment.overdue"); err == nil {
+		t.Fatal("expected the timed transition to be denied")
+	}
+
+	var due []Timer
+	r.Scheduler.fire = func(_ context.Context, tm Timer) error {
+		due = append(due, tm)
+		return nil
+	}
+	clock.Advance(72 * time.Hour)
+	if _, err = r.Scheduler.FireDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 {
+		t.Fatalf("expected 1 timer to fire, got %d", len(due))
+	}
+	if err = r.consumeTimer(ctx, due[0]); err != nil {
+		t.Fatal(err)
+	}
+	current, _ := sm.State(ctx)
+	if current != "Overdue" {
+		t.Fatalf("expected state Overdue, got %v", current)
+	}
+
+	// Overdue is a Final State, so the Reconciler stops consuming events.
+	select {
+	case <-r.done:
+	default:
+		t.Fatal("expected reconciler to complete in final state")
+	}
+}
+
+func TestReconcilerScheduleVariables(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c, err := GetFSContract("./tests/timers_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Status.Variables = map[string]string{"signingDeadline": "2025-02-28T00:00:00Z"}
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithClock(clock), WithReconcilerLogger(logger))
+
+	// The timer is due at the current value of the Variable, not its Default.
+	draft, _ := c.GetState("Draft")
+	if err = r.schedule(ctx, draft); err != nil {
+		t.Fatal(err)
+	}
+	tm, err := r.Scheduler.store.Get(ctx, timerID("Draft", "Signing Deadline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC); !tm.FireAt.Equal(expected) {
+		t.Fatalf("expected timer at %v, got %v", expected, tm.FireAt)
+	}
+}
+
+func TestValidateTimers(t *testing.T) {
+	tests := []struct {
+		name string
+		tr   Transition
+		err  bool
+	}{
+		{name: "After", tr: Transition{Name: "a", After: "24h"}},
+		{name: "At", tr: Transition{Name: "a", At: "2025-01-31T00:00:00Z"}},
+		{name: "At variable", tr: Transition{Name: "a", At: "$signingDeadline"}},
+		{name: "Invalid duration", tr: Transition{Name: "a", After: "3 days"}, err: true},
+		{name: "Invalid timestamp", tr: Transition{Name: "a", At: "2025-01-31"}, err: true},
+		{name: "Undeclared variable", tr: Transition{Name: "a", At: "$missing"}, err: true},
+		{name: "After and At", tr: Transition{Name: "a", After: "24h", At: "2025-01-31T00:00:00Z"}, err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Contract{State: StateConfiguration{States: []State{{
+				Name:        "Draft",
+				Variables:   []Variables{{Name: "signingDeadline", Type: "string"}},
+				Transitions: []Transition{test.tr},
+			}}}}
+			err := c.ValidateTimers()
+			if err != nil && !test.err {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if err == nil && test.err {
+				t.Fatal("expected error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package slc
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidInternalTransition = errors.New("internal transition must remain in its state")
+	ErrDuplicateTransition       = errors.New("transition name is declared more than once by the state")
+)
+
+// ValidateTransitions validates that the Transitions of a State have distinct Names, and that internal
+// Transitions lead to the State declaring them and are not triggered by a join of Regions.
+func (c *Contract) ValidateTransitions() error {
+	for _, s := range c.States() {
+		names := make(map[string]bool, len(s.Transitions))
+		for _, t := range s.Transitions {
+			if names[t.Name] {
+				return fmt.Errorf("%w: %s (state %s)", ErrDuplicateTransition, t.Name, s.Name)
+			}
+			names[t.Name] = true
+			if !t.Internal {
+				continue
+			}
+			if t.To != s.Name {
+				return fmt.Errorf("%w: %s (state %s) leads to %s", ErrInvalidInternalTransition, t.Name, s.Name, t.To)
+			}
+			if len(t.Join) > 0 {
+				return fmt.Errorf("%w: %s (state %s) is a join transition", ErrInvalidInternalTransition, t.Name, s.Name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package slc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcilerSelfTransitions(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	c, err := GetFSContract("./tests/transitions_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "In Process", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := runtime.NewScheme()
+	if err = kustomizev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme).Build()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithKubernetesClient(k8s), WithClock(clock), WithReconcilerLogger(logger))
+
+	state, _ := c.GetState("In Process")
+	if err = r.Scheduler.Schedule(ctx, state); err != nil {
+		t.Fatal(err)
+	}
+	timerFireAt := func() time.Time {
+		tm, err := r.Scheduler.store.Get(ctx, timerID("In Process", "Overdue"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm.FireAt
+	}
+	scheduled := timerFireAt()
+
+	exists := func(name string) bool {
+		err := k8s.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &kustomizev1.Kustomization{})
+		return err == nil
+	}
+	deleteAll := func() {
+		if err := k8s.DeleteAllOf(ctx, &kustomizev1.Kustomization{}, client.InNamespace("default")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fire := func(eventType string) {
+		evt, err := c.CreateEvent(eventType, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+		if current, _ := sm.State(ctx); current != "In Process" {
+			t.Fatalf("expected state In Process, got %v", current)
+		}
+	}
+
+	// An internal Transition runs its Actions without exiting or entering the State.
+	clock.Advance(time.Hour)
+	fire("com.decombine.payment.partial")
+	if !exists("partial-payment") || exists("in-process-exit") || exists("in-process-entry") {
+		t.Fatal("expected only the internal transition actions to run")
+	}
+	if !timerFireAt().Equal(scheduled) {
+		t.Fatal("expected internal transition to keep the timer of the state")
+	}
+	deleteAll()
+
+	// A reentrant Transition exits and re-enters the State, restarting its timers.
+	fire("com.decombine.contract.restart")
+	if !exists("in-process-exit") || !exists("restart") || !exists("in-process-entry") {
+		t.Fatal("expected exit, transition and entry actions to run")
+	}
+	if !timerFireAt().Equal(scheduled.Add(time.Hour)) {
+		t.Fatal("expected reentrant transition to restart the timer of the state")
+	}
+}
+
+func TestReconcilerSharedEvent(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both Transitions of the State are triggered by the event, and their guards select one.
+	c.State.States[0].Transitions = []Transition{
+		{Name: "Review", To: "Review", On: "com.decombine.order.approve", Conditions: []Condition{{Name: "large.order", Engine: EngineCEL, Value: "input.amount > 1000"}}},
+		{Name: "Approval", To: "Approved", On: "com.decombine.order.approve", Conditions: []Condition{{Name: "small.order", Engine: EngineCEL, Value: "input.amount <= 1000"}}},
+	}
+	c.State.States = append(c.State.States, State{Name: "Review"})
+	if err = c.ValidateTransitions(); err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithReconcilerLogger(logger))
+
+	event := simulationEvent(t, c, "com.decombine.order.approve", "", map[string]interface{}{"amount": 500})
+	eligible, err := r.eligibleTransitions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = r.ConsumeEvent(ctx, &event, eligible); err != nil {
+		t.Fatal(err)
+	}
+
+	// The Transition that fired is the one audited.
+	if current, _ := sm.State(ctx); current != "Approved" {
+		t.Fatalf("expected state Approved, got %v", current)
+	}
+	if len(c.Status.Audit) != 1 {
+		t.Fatalf("expected 1 audit entry, got %v", c.Status.Audit)
+	}
+	if entry := c.Status.Audit[0]; entry.To != "Approved" || !equalSlices(entry.Details, []string{"transition Approval"}) {
+		t.Fatalf("unexpected audit entry %+v", entry)
+	}
+}
+
+func TestValidateTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Contract)
+		err    error
+	}{
+		{
+			name:   "Valid",
+			modify: func(c *Contract) {},
+		},
+		{
+			name: "Internal transition to another state",
+			modify: func(c *Contract) {
+				c.State.States[0].Transitions[0].To = "Overdue"
+			},
+			err: ErrInvalidInternalTransition,
+		},
+		{
+			name: "Duplicate transition name",
+			modify: func(c *Contract) {
+				c.State.States[0].Transitions[1].Name = c.State.States[0].Transitions[0].Name
+			},
+			err: ErrDuplicateTransition,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := GetFSContract("./tests/transitions_ok.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.modify(c)
+			if err = c.ValidateTransitions(); !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
@@ -33,6 +33,9 @@ func ValidateJSONPayload(in []byte) (*Contract, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
@@ -49,6 +52,9 @@ func ValidateYAMLPayload(in []byte) (*Contract, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
@@ -65,9 +71,44 @@ func ValidateTOMLPayload(in []byte) (*Contract, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err = c.Validate(); err != nil {
+		return nil, err
+	}
 	return &c, nil
 }
 
+// Validate performs the semantic validation of a Contract that cannot be expressed by struct tags.
+func (c *Contract) Validate() error {
+	if err := c.ValidateParties(); err != nil {
+		return err
+	}
+	if err := c.ValidateRegions(); err != nil {
+		return err
+	}
+	if err := c.ValidateHierarchy(); err != nil {
+		return err
+	}
+	if err := c.ValidateTransitions(); err != nil {
+		return err
+	}
+	if err := c.ValidateConditions(); err != nil {
+		return err
+	}
+	if err := c.ValidateSchemas(); err != nil {
+		return err
+	}
+	if err := c.ValidateVariables(); err != nil {
+		return err
+	}
+	if err := c.ValidateTimers(); err != nil {
+		return err
+	}
+	if err := c.ValidateFinalStates(); err != nil {
+		return err
+	}
+	return nil
+}
+
 // ValidateRepository accepts a GitSource and validates the target
 // repository exists, is accessible, and at minimum a contract.json.
 func ValidateRepository(ctx context.Context, token, uri, branch, path string) (string, error) {
@@ -3,6 +3,8 @@ package slc
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"testing"
 
@@ -87,7 +89,7 @@ func TestValidateJSONPayload(t *testing.T) {
 			name:  "invalid contract",
 			input: []byte(`{"name":"test","version":"0.0.1","text":{"url":"https://example.com"},"source":{"url":"https://example.com"}}`),
 			expected: []string{
-				`Key: 'Contract.Policy.URL' Error:Field validation for 'URL' failed on the 'required' tag`,
+				`Key: 'Contract.Policy.URL' Error:Field validation for 'URL' failed on the 'required_without' tag`,
 				`Key: 'Contract.State' Error:Field validation for 'State' failed on the 'required' tag`,
 			},
 		},
@@ -132,6 +134,31 @@ func TestValidateYAMLPayload(t *testing.T) {
 			path: "tests/kustomization_ok.yaml",
 			err:  false,
 		},
+		{
+			name: "Parties Ok",
+			path: "tests/parties_ok.yaml",
+			err:  false,
+		},
+		{
+			name: "Parties Undeclared Role",
+			path: "tests/parties_undeclared_role.yaml",
+			err:  true,
+		},
+		{
+			name: "Conditions Ok",
+			path: "tests/conditions_ok.yaml",
+			err:  false,
+		},
+		{
+			name: "Conditions Invalid Rego",
+			path: "tests/conditions_invalid_rego.yaml",
+			err:  true,
+		},
+		{
+			name: "Schemas Ok",
+			path: "tests/schemas_ok.yaml",
+			err:  false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -188,6 +215,58 @@ func TestValidateTOMLPayload(t *testing.T) {
 	}
 }
 
+func TestValidateFinalStates(t *testing.T) {
+	tests := []struct {
+		name   string
+		states []State
+		err    error
+	}{
+		{
+			name: "Reachable final state",
+			states: []State{
+				{Name: "Draft", Transitions: []Transition{{Name: "Sign", To: "Active", On: "sign"}}},
+				{Name: "Active", Transitions: []Transition{{Name: "Fulfil", To: "Fulfilled", On: "fulfil"}}},
+				{Name: "Fulfilled", Final: true},
+			},
+		},
+		{
+			name: "No final state",
+			states: []State{
+				{Name: "Draft", Transitions: []Transition{{Name: "Sign", To: "Active", On: "sign"}}},
+				{Name: "Active", Transitions: []Transition{}},
+			},
+			err: ErrNoFinalState,
+		},
+		{
+			name: "Unreachable final state",
+			states: []State{
+				{Name: "Draft", Transitions: []Transition{{Name: "Sign", To: "Active", On: "sign"}}},
+				{Name: "Active", Transitions: []Transition{{Name: "Revert", To: "Draft", On: "revert"}}},
+				{Name: "Fulfilled", Final: true},
+			},
+			err: ErrNoFinalState,
+		},
+		{
+			name: "Final state with transitions",
+			states: []State{
+				{Name: "Draft", Transitions: []Transition{{Name: "Sign", To: "Fulfilled", On: "sign"}}},
+				{Name: "Fulfilled", Final: true, Transitions: []Transition{{Name: "Reopen", To: "Draft", On: "reopen"}}},
+			},
+			err: ErrFinalStateTransitions,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := Contract{State: StateConfiguration{Initial: "Draft", States: test.states}}
+			err := c.ValidateFinalStates()
+			if !errors.Is(err, test.err) {
+				t.Fatalf("expected %v, got %v", test.err, err)
+			}
+		})
+	}
+}
+
 func TestValidateRepository(t *testing.T) {
 	type tests struct {
 		shouldErr bool
@@ -231,3 +310,30 @@ func equalSlices(a, b []string) bool {
 	}
 	return true
 }
+
+func TestStatusCurrentState(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		unmarshal func([]byte, interface{}) error
+		expected  []string
+	}{
+		{name: "JSON scalar", input: `{"currentState": "Draft", "sourceState": "Ready"}`, unmarshal: json.Unmarshal, expected: []string{"Draft"}},
+		{name: "JSON list", input: `{"currentState": ["Active", "Paid"]}`, unmarshal: json.Unmarshal, expected: []string{"Active", "Paid"}},
+		{name: "YAML scalar", input: "currentState: Draft\nsourceState: Ready\n", unmarshal: yaml.Unmarshal, expected: []string{"Draft"}},
+		{name: "YAML list", input: "currentState:\n  - Active\n  - Paid\n", unmarshal: yaml.Unmarshal, expected: []string{"Active", "Paid"}},
+		{name: "TOML scalar", input: "currentState = \"Draft\"\nsourceState = \"Ready\"\n", unmarshal: toml.Unmarshal, expected: []string{"Draft"}},
+		{name: "TOML list", input: "currentState = [\"Active\", \"Paid\"]\n", unmarshal: toml.Unmarshal, expected: []string{"Active", "Paid"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var status Status
+			if err := tc.unmarshal([]byte(tc.input), &status); err != nil {
+				t.Fatal(err)
+			}
+			if !equalSlices(status.CurrentState, tc.expected) {
+				t.Fatalf("expected current state %v, got %v", tc.expected, status.CurrentState)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+)
+
+// VariableKindConcerto is the Kind of Variables whose Ref is resolved against Concerto objects in event data.
+const VariableKindConcerto = "concerto"
+
+var (
+	ErrInvalidJSONPath = errors.New("invalid jsonpath")
+)
+
+// Resolve resolves the Ref of the Variable against event data. For Concerto Variables, the Ref is the
+// fully qualified name of a Concerto class followed by the path of a property, and is resolved against
+// the objects of the data declaring that "$class". E.g., "com.decombine.reviewer-slc.reviewer.id"
+// For other Variables, a Ref starting with "$" is a JSONPath into the data. E.g., "$.reviewer.id"
+// Resolve reports false if the Variable has no Ref, or the Ref is not found in the data.
+func (v Variables) Resolve(data interface{}) (interface{}, bool) {
+	if v.Ref == "" {
+		return nil, false
+	}
+	if strings.EqualFold(v.Kind, VariableKindConcerto) {
+		objects, err := ParseConcertoPayload(data)
+		if err != nil {
+			return nil, false
+		}
+		for _, o := range objects {
+			class, _ := o["$class"].(string)
+			path, ok := concertoPropertyPath(class, v.Ref)
+			if !ok {
+				continue
+			}
+			if value, err := resolveJSONPath(o, "$."+path); err == nil {
+				return value, true
+			}
+		}
+		return nil, false
+	}
+	if strings.HasPrefix(v.Ref, "$") {
+		value, err := resolveJSONPath(data, v.Ref)
+		return value, err == nil
+	}
+	return nil, false
+}
+
+// concertoPropertyPath returns the property path of a Ref relative to a Concerto class. The version of the
+// class namespace is ignored, and the class name is matched case insensitively.
+// E.g., "com.decombine.reviewer-slc@1.0.0.Reviewer" and "com.decombine.reviewer-slc.reviewer.id" yield "id".
+func concertoPropertyPath(class, ref string) (string, bool) {
+	if class == "" {
+		return "", false
+	}
+	if at := strings.Index(class, "@"); at >= 0 {
+		if dot := strings.LastIndex(class, "."); dot > at {
+			class = class[:at] + class[dot:]
+		}
+	}
+	prefix := strings.ToLower(class) + "."
+	if !strings.HasPrefix(strings.ToLower(ref), prefix) {
+		return "", false
+	}
+	return ref[len(prefix):], true
+}
+
+// parseJSONPath parses a JSONPath into its segments. Only child members, in dot or bracket notation, and
+// array indices are supported. E.g., "$.items[0].id", "$['reviewer']['id']"
+func parseJSONPath(path string) ([]interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("%w: %s: must start with $", ErrInvalidJSONPath, path)
+	}
+	var segments []interface{}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("%w: %s: empty member", ErrInvalidJSONPath, path)
+			}
+			segments = append(segments, rest[:end])
+			rest = rest[end:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: %s: unterminated bracket", ErrInvalidJSONPath, path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segments = append(segments, inner[1:len(inner)-1])
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: unsupported selector [%s]", ErrInvalidJSONPath, path, inner)
+			}
+			segments = append(segments, i)
+		default:
+			return nil, fmt.Errorf("%w: %s: unexpected %q", ErrInvalidJSONPath, path, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+// resolveJSONPath resolves a JSONPath in the data.
+func resolveJSONPath(data interface{}, path string) (interface{}, error) {
+	segments, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := data
+	for _, segment := range segments {
+		switch s := segment.(type) {
+		case string:
+			m, ok := current.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: %s is not an object member", ErrInvalidJSONPath, path, s)
+			}
+			if current, ok = m[s]; !ok {
+				return nil, fmt.Errorf("%w: %s: %s not found", ErrInvalidJSONPath, path, s)
+			}
+		case int:
+			a, ok := current.([]interface{})
+			if !ok || s < 0 || s >= len(a) {
+				return nil, fmt.Errorf("%w: %s: index %d not found", ErrInvalidJSONPath, path, s)
+			}
+			current = a[s]
+		}
+	}
+	return current, nil
+}
+
+// variableString formats a resolved value as a Variable value. Strings are kept as is, and other values
+// are encoded as JSON.
+func variableString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(value)
+	return string(b)
+}
+
+// ResolvedVariables returns the Variables of a State with the Value of each Variable set to its current value
+// in the Contract Status, or to its Default if not set. The Default remains the value declared by the Contract.
+func (c *Contract) ResolvedVariables(state string) ([]Variables, error) {
+	vars, err := c.GetVariables(state)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make([]Variables, len(vars))
+	for i, v := range vars {
+		v.Value = v.Default
+		if value, ok := c.Status.Variables[v.Name]; ok {
+			v.Value = value
+		}
+		resolved[i] = v
+	}
+	return resolved, nil
+}
+
+// current returns the Value of the Variable, or its Default if the Variable is not resolved.
+func (v Variables) current() string {
+	if v.Value != "" {
+		return v.Value
+	}
+	return v.Default
+}
+
+// ValidateVariables validates that the Refs of Variables that are not Concerto Variables are valid JSONPaths.
+func (c *Contract) ValidateVariables() error {
+	for _, s := range c.States() {
+		for _, v := range s.Variables {
+			if strings.EqualFold(v.Kind, VariableKindConcerto) || !strings.HasPrefix(v.Ref, "$") {
+				continue
+			}
+			if _, err := parseJSONPath(v.Ref); err != nil {
+				return fmt.Errorf("%w (state %s, variable %s)", err, s.Name, v.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// resolveVariables resolves the Variables of the active States of a Region, or of the StateConfiguration for
+// "", against event data, returning the values of the Variables found in the data by name.
+func (r *Reconciler) resolveVariables(ctx context.Context, region string, data interface{}) map[string]string {
+	active, err := r.activeStates(ctx, region)
+	if err != nil {
+		r.Logger.Error("Error getting active states", "error", err)
+		return nil
+	}
+	var values map[string]string
+	for _, s := range active {
+		for _, v := range s.Variables {
+			value, ok := v.Resolve(data)
+			if !ok {
+				continue
+			}
+			if values == nil {
+				values = make(map[string]string)
+			}
+			values[v.Name] = variableString(value)
+		}
+	}
+	return values
+}
+
+// updateVariables records the values of Variables in the Contract Status.
+func (r *Reconciler) updateVariables(values map[string]string) {
+	if len(values) == 0 {
+		return
+	}
+	if r.Contract.Status.Variables == nil {
+		r.Contract.Status.Variables = make(map[string]string)
+	}
+	for name, value := range values {
+		r.Logger.Info("Variable updated", "variable", name, "value", value)
+		r.Contract.Status.Variables[name] = value
+	}
+}
+
+// substituteVariables makes the current values of Variables available to the manifests of a Kustomization
+// through post-build substitution, e.g., "${reviewerUniqueId}". Substitutions declared by the Kustomization
+// take precedence.
+func (r *Reconciler) substituteVariables(spec *kustomizev1.KustomizationSpec) {
+	if len(r.Contract.Status.Variables) == 0 {
+		return
+	}
+	if spec.PostBuild == nil {
+		spec.PostBuild = &kustomizev1.PostBuild{}
+	} else {
+		spec.PostBuild = spec.PostBuild.DeepCopy()
+	}
+	if spec.PostBuild.Substitute == nil {
+		spec.PostBuild.Substitute = make(map[string]string)
+	}
+	for name, value := range r.Contract.Status.Variables {
+		if _, ok := spec.PostBuild.Substitute[name]; !ok {
+			spec.PostBuild.Substitute[name] = value
+		}
+	}
+}
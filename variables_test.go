@@ -0,0 +1,133 @@
+package slc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	kustomizev1 "github.com/fluxcd/kustomize-controller/api/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestVariablesResolve(t *testing.T) {
+	data := map[string]interface{}{
+		"order": map[string]interface{}{
+			"amount": 5000.0,
+			"items":  []interface{}{map[string]interface{}{"sku": "A-1"}},
+		},
+		"review": map[string]interface{}{
+			"$class":   "com.decombine.reviewer-slc@1.0.0.Reviewer",
+			"id":       "reviewer-42",
+			"approved": true,
+		},
+	}
+
+	tests := []struct {
+		name     string
+		variable Variables
+		value    interface{}
+		found    bool
+	}{
+		{name: "JSONPath", variable: Variables{Ref: "$.order.amount"}, value: 5000.0, found: true},
+		{name: "JSONPath bracket and index", variable: Variables{Ref: "$['order'].items[0].sku"}, value: "A-1", found: true},
+		{name: "JSONPath not found", variable: Variables{Ref: "$.order.currency"}},
+		{name: "JSONPath index out of range", variable: Variables{Ref: "$.order.items[1]"}},
+		{name: "Concerto", variable: Variables{Ref: "com.decombine.reviewer-slc.reviewer.id", Kind: "concerto"}, value: "reviewer-42", found: true},
+		{name: "Concerto other class", variable: Variables{Ref: "com.decombine.payment-slc.payment.id", Kind: "concerto"}},
+		{name: "No ref", variable: Variables{Name: "amount"}},
+		{name: "Unsupported ref", variable: Variables{Ref: "order.amount"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, found := test.variable.Resolve(data)
+			if found != test.found {
+				t.Fatalf("expected found %v, got %v", test.found, found)
+			}
+			if value != test.value {
+				t.Fatalf("expected %v, got %v", test.value, value)
+			}
+		})
+	}
+}
+
+func TestReconcilerVariables(t *testing.T) {
+	ctx := context.Background()
+	c, err := GetFSContract("./tests/variables_ok.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sm, err := NewStateMachine(ctx, "Draft", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheme := runtime.NewScheme()
+	if err = kustomizev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	k8s := fake.NewClientBuilder().WithScheme(scheme).Build()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := NewReconciler(c, sm, nil, nil, ReconcilerConfig{}, WithKubernetesClient(k8s), WithReconcilerLogger(logger))
+
+	consume := func(eventType string, data interface{}) {
+		evt, err := c.CreateEvent(eventType, "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		payload, _ := json.Marshal(data)
+		if err = evt.SetData("application/json", payload); err != nil {
+			t.Fatal(err)
+		}
+		eligible, err := r.eligibleTransitions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.ConsumeEvent(ctx, &evt, eligible); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The guard is not satisfied by the Default of the Variable.
+	consume("com.decombine.order.approve", map[string]interface{}{})
+	if state, _ := sm.State(ctx); state != "Draft" {
+		t.Fatalf("expected state Draft, got %v", state)
+	}
+
+	// Events that trigger no Transition, or whose Transition is denied, do not update Variables.
+	consume("com.decombine.order.updated", map[string]interface{}{"order": map[string]interface{}{"amount": 5000}})
+	consume("com.decombine.order.approve", map[string]interface{}{"order": map[string]interface{}{"amount": 500}})
+	if state, _ := sm.State(ctx); state != "Draft" || len(c.Status.Variables) != 0 {
+		t.Fatalf("unexpected state %v and variables %v", state, c.Status.Variables)
+	}
+
+	// The guard sees the Variables of the event, which are saved once the Transition fires.
+	consume("com.decombine.order.approve", map[string]interface{}{
+		"order":    map[string]interface{}{"amount": 5000},
+		"reviewer": map[string]interface{}{"$class": "com.decombine.reviewer-slc.Reviewer", "id": "reviewer-42"},
+	})
+	if state, _ := sm.State(ctx); state != "Approved" {
+		t.Fatalf("expected state Approved, got %v", state)
+	}
+	expected := map[string]string{"amount": "5000", "reviewerUniqueId": "reviewer-42"}
+	for name, value := range expected {
+		if c.Status.Variables[name] != value {
+			t.Fatalf("expected variable %s to be %s, got %s", name, value, c.Status.Variables[name])
+		}
+	}
+
+	k := &kustomizev1.Kustomization{}
+	if err = k8s.Get(ctx, types.NamespacedName{Name: "approved", Namespace: "default"}, k); err != nil {
+		t.Fatal(err)
+	}
+	substitute := k.Spec.PostBuild.Substitute
+	if substitute["reviewerUniqueId"] != "reviewer-42" || substitute["amount"] != "declared" {
+		t.Fatalf("unexpected substitutions: %v", substitute)
+	}
+	if state, _ := c.GetState("Approved"); state.Entry.KubernetesActions[0].KustomizationSpec.PostBuild.Substitute["reviewerUniqueId"] != "" {
+		t.Fatal("expected contract actions to be left unchanged")
+	}
+}